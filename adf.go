@@ -0,0 +1,110 @@
+package jt
+
+import (
+	"strings"
+)
+
+// setDescription converts the plain text description into an Atlassian
+// Document Format document.
+//
+// Paragraphs are separated by blank lines and line breaks within a paragraph
+// are kept. Lines starting with one to six '#' followed by a space become
+// headings, and fenced code blocks are kept verbatim as code blocks.
+// https://developer.atlassian.com/cloud/jira/platform/apis/document/structure/
+func setDescription(msg string) *Description {
+	desc := Description{
+		Type:    "doc",
+		Version: 1,
+	}
+
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		p := Content{Type: "paragraph"}
+		for i, line := range paragraph {
+			if i > 0 {
+				p.Content = append(p.Content, ContentBlock{Type: "hardBreak"})
+			}
+			p.Content = append(p.Content, ContentBlock{Type: "text", Text: line})
+		}
+		desc.Content = append(desc.Content, p)
+		paragraph = nil
+	}
+
+	var code []string
+	var codeLang string
+	inFence := false
+
+	for _, line := range strings.Split(strings.TrimRight(msg, "\n"), "\n") {
+		if isFence(line) {
+			if !inFence {
+				flushParagraph()
+				codeLang = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "`~"))
+				inFence = true
+				continue
+			}
+			desc.Content = append(desc.Content, codeBlock(codeLang, code))
+			code, codeLang, inFence = nil, "", false
+			continue
+		}
+		if inFence {
+			code = append(code, line)
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			continue
+		}
+
+		if level, text, ok := heading(line); ok {
+			flushParagraph()
+			desc.Content = append(desc.Content, Content{
+				Type:    "heading",
+				Attrs:   Attrs{"level": level},
+				Content: []ContentBlock{{Type: "text", Text: text}},
+			})
+			continue
+		}
+
+		paragraph = append(paragraph, line)
+	}
+
+	// An unclosed code block runs to the end of the description
+	if inFence {
+		desc.Content = append(desc.Content, codeBlock(codeLang, code))
+	}
+	flushParagraph()
+
+	return &desc
+}
+
+func codeBlock(lang string, lines []string) Content {
+	c := Content{Type: "codeBlock"}
+	if lang != "" {
+		c.Attrs = Attrs{"language": lang}
+	}
+	// Text nodes can't be empty
+	if text := strings.Join(lines, "\n"); text != "" {
+		c.Content = []ContentBlock{{Type: "text", Text: text}}
+	}
+	return c
+}
+
+// heading returns the level and text of a Markdown style "# Heading" line.
+func heading(line string) (int, string, bool) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(line[level:])
+	if text == "" {
+		return 0, "", false
+	}
+	return level, text, true
+}
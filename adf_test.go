@@ -0,0 +1,66 @@
+package jt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSetDescription(t *testing.T) {
+	testData := []struct {
+		name     string
+		msg      string
+		expected string
+	}{
+		{
+			name:     "paragraphs",
+			msg:      "First paragraph\n\nSecond paragraph\n",
+			expected: `[{"type":"paragraph","content":[{"type":"text","text":"First paragraph"}]},{"type":"paragraph","content":[{"type":"text","text":"Second paragraph"}]}]`,
+		},
+		{
+			name:     "hard breaks",
+			msg:      "First line\nSecond line",
+			expected: `[{"type":"paragraph","content":[{"type":"text","text":"First line"},{"type":"hardBreak"},{"type":"text","text":"Second line"}]}]`,
+		},
+		{
+			name:     "headings",
+			msg:      "# Title\ntext\n### Section",
+			expected: `[{"type":"heading","attrs":{"level":1},"content":[{"type":"text","text":"Title"}]},{"type":"paragraph","content":[{"type":"text","text":"text"}]},{"type":"heading","attrs":{"level":3},"content":[{"type":"text","text":"Section"}]}]`,
+		},
+		{
+			name:     "not headings",
+			msg:      "#hashtag\n####### seven",
+			expected: `[{"type":"paragraph","content":[{"type":"text","text":"#hashtag"},{"type":"hardBreak"},{"type":"text","text":"####### seven"}]}]`,
+		},
+		{
+			name:     "code block",
+			msg:      "Run:\n```sh\ngo test ./...\n\n# comment\n```\nDone",
+			expected: `[{"type":"paragraph","content":[{"type":"text","text":"Run:"}]},{"type":"codeBlock","attrs":{"language":"sh"},"content":[{"type":"text","text":"go test ./...\n\n# comment"}]},{"type":"paragraph","content":[{"type":"text","text":"Done"}]}]`,
+		},
+		{
+			name:     "empty code block",
+			msg:      "```\n```",
+			expected: `[{"type":"codeBlock"}]`,
+		},
+		{
+			name:     "unclosed code block",
+			msg:      "~~~\ncode",
+			expected: `[{"type":"codeBlock","content":[{"type":"text","text":"code"}]}]`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := setDescription(tt.msg)
+			if desc.Type != "doc" || desc.Version != 1 {
+				t.Fatalf("expected a version 1 doc, got %q version %d", desc.Type, desc.Version)
+			}
+			got, err := json.Marshal(desc.Content)
+			if err != nil {
+				t.Fatalf("failed to marshal content: %s", err)
+			}
+			if string(got) != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
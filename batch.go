@@ -0,0 +1,114 @@
+package jt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// batchSeparator separates issues in the batch editor buffer.
+	batchSeparator = "==="
+
+	// batchParentPrefix refers to another issue in the same batch in the
+	// Parent header, by its position. Example: "Parent: #1".
+	batchParentPrefix = "#"
+)
+
+var batchHelpText = []string{
+	"Do not modify or remove the line above.",
+	"Everything below it will be ignored.",
+	"",
+	"Enter one issue per block, separating blocks with a line containing only '" + batchSeparator + "'.",
+	"Each block has an optional header, a summary and a description like a single issue.",
+	"Blocks without a summary are skipped.",
+	"",
+	"Set the parent to '" + batchParentPrefix + "N' to nest the issue under the Nth issue of the batch,",
+	"which has to come before it. Example: 'Parent: " + batchParentPrefix + "1'.",
+	`Labels and Components are comma separated, Assignee can be "me".`,
+}
+
+// BatchIssue is an issue parsed from the batch editor buffer.
+type BatchIssue struct {
+	IssueConfig
+	// ParentRef is the position, starting at 1, of the parent issue in the
+	// batch, or 0 if the parent isn't part of the batch.
+	ParentRef int
+}
+
+// RenderBatchBuffer renders the batch editor buffer, with two issue blocks
+// pre-populated with the issue config.
+func RenderBatchBuffer(ic IssueConfig, opts EditorOptions) string {
+	return RenderBatchIssues([]BatchIssue{{IssueConfig: ic}, {IssueConfig: ic}}, opts)
+}
+
+// RenderBatchIssues renders the issues into the batch editor buffer.
+func RenderBatchIssues(issues []BatchIssue, opts EditorOptions) string {
+	var buf strings.Builder
+	for i, issue := range issues {
+		if i > 0 {
+			buf.WriteString("\n" + batchSeparator + "\n")
+		}
+		if issue.ParentRef > 0 {
+			issue.ParentIssueKey = batchParentPrefix + strconv.Itoa(issue.ParentRef)
+		}
+		renderIssue(&buf, issue.IssueConfig)
+	}
+	buf.WriteString("\n")
+	renderHelp(&buf, batchHelpText, opts)
+	return buf.String()
+}
+
+// ParseBatchBuffer parses the batch editor buffer into issues, using a copy of
+// the provided issue config for each one.
+// It returns ErrEmptySummary if none of the blocks have a summary.
+func ParseBatchBuffer(buf string, ic IssueConfig, opts EditorOptions) ([]BatchIssue, error) {
+	lines := cutScissors(strings.Split(buf, "\n"), opts.commentChar())
+
+	// Split the buffer into blocks, ignoring separators in code blocks
+	var blocks []string
+	start := 0
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if !inFence && strings.TrimSpace(line) == batchSeparator {
+			blocks = append(blocks, strings.Join(lines[start:i], "\n"))
+			start = i + 1
+		}
+	}
+	blocks = append(blocks, strings.Join(lines[start:], "\n"))
+
+	// Issues are numbered like the parent references, skipping empty blocks
+	var issues []BatchIssue
+	for _, block := range blocks {
+		parsed, err := ParseBuffer(block, ic, opts)
+		if errors.Is(err, ErrEmptySummary) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("issue %d: %w", len(issues)+1, err)
+		}
+
+		issue := BatchIssue{IssueConfig: parsed}
+		if ref, ok := strings.CutPrefix(parsed.ParentIssueKey, batchParentPrefix); ok {
+			n, err := strconv.Atoi(ref)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("issue %d: invalid parent %q, expected %sN", len(issues)+1, parsed.ParentIssueKey, batchParentPrefix)
+			}
+			if n > len(issues) {
+				return nil, fmt.Errorf("issue %d: parent %s must come before the issue", len(issues)+1, parsed.ParentIssueKey)
+			}
+			issue.ParentRef = n
+			issue.ParentIssueKey = ""
+		}
+		issues = append(issues, issue)
+	}
+
+	if len(issues) == 0 {
+		return nil, ErrEmptySummary
+	}
+	return issues, nil
+}
@@ -0,0 +1,123 @@
+package jt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchBuffer(t *testing.T) {
+	buf := `---
+Type: Epic
+---
+The epic
+
+Epic description
+===
+---
+Type: Story
+Parent: #1
+---
+First story
+` + "```" + `
+===
+` + "```" + `
+===
+---
+Type: Task
+---
+===
+Second story
+# ------------------------ >8 ------------------------
+===
+Ignored
+`
+
+	issues, err := ParseBatchBuffer(buf, IssueConfig{ProjectKey: "PRJ", IssueType: "Task"}, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+
+	expected := []BatchIssue{
+		{IssueConfig: IssueConfig{ProjectKey: "PRJ", IssueType: "Epic", Summary: "The epic", Description: "Epic description\n"}},
+		{IssueConfig: IssueConfig{ProjectKey: "PRJ", IssueType: "Story", Summary: "First story", Description: "```\n===\n```\n"}, ParentRef: 1},
+		{IssueConfig: IssueConfig{ProjectKey: "PRJ", IssueType: "Task", Summary: "Second story"}},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i := range expected {
+		got, exp := issues[i], expected[i]
+		if got.Summary != exp.Summary || got.Description != exp.Description || got.IssueType != exp.IssueType ||
+			got.ProjectKey != exp.ProjectKey || got.ParentRef != exp.ParentRef || got.ParentIssueKey != "" {
+			t.Errorf("issue %d: expected %+v, got %+v", i+1, exp, got)
+		}
+	}
+}
+
+func TestRenderBatchIssuesRoundTrip(t *testing.T) {
+	issues := []BatchIssue{
+		{IssueConfig: IssueConfig{IssueType: "Epic", Summary: "The epic"}},
+		{IssueConfig: IssueConfig{IssueType: "Story", Summary: "A story", ParentIssueKey: "PRJ-1"}},
+		{IssueConfig: IssueConfig{IssueType: "Story", Summary: "Another story"}, ParentRef: 1},
+	}
+
+	got, err := ParseBatchBuffer(RenderBatchIssues(issues, EditorOptions{}), IssueConfig{}, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+	if len(got) != len(issues) {
+		t.Fatalf("expected %d issues, got %d", len(issues), len(got))
+	}
+	for i := range issues {
+		if got[i].Summary != issues[i].Summary || got[i].ParentIssueKey != issues[i].ParentIssueKey || got[i].ParentRef != issues[i].ParentRef {
+			t.Errorf("issue %d: expected %+v, got %+v", i+1, issues[i], got[i])
+		}
+	}
+}
+
+func TestParseBatchBufferErrors(t *testing.T) {
+	testData := []struct {
+		name   string
+		buf    string
+		errMsg string
+	}{
+		{
+			name:   "parent after issue",
+			buf:    "---\nParent: #2\n---\nStory\n===\nEpic\n",
+			errMsg: "issue 1: parent #2 must come before the issue",
+		},
+		{
+			name:   "invalid parent",
+			buf:    "Epic\n===\n---\nParent: #one\n---\nStory\n",
+			errMsg: "issue 2: invalid parent",
+		},
+		{
+			name:   "invalid header",
+			buf:    "Epic\n===\n---\nPriority: High\n---\nStory\n",
+			errMsg: "issue 2: unknown header",
+		},
+		{
+			name:   "invalid header after empty block",
+			buf:    "Epic\n===\n\n===\n---\nPriority: High\n---\nStory\n",
+			errMsg: "issue 2: unknown header",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBatchBuffer(tt.buf, IssueConfig{}, EditorOptions{})
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+
+	_, err := ParseBatchBuffer(RenderBatchBuffer(IssueConfig{IssueType: "Task"}, EditorOptions{}), IssueConfig{}, EditorOptions{})
+	if !errors.Is(err, ErrEmptySummary) {
+		t.Fatalf("expected %q for a buffer without summaries, got %v", ErrEmptySummary, err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/leosunmo/jt"
+)
+
+// batchResult is the outcome of creating one issue of a batch.
+type batchResult struct {
+	issue   jt.BatchIssue
+	created jt.CreatedIssueResponse
+	err     error
+}
+
+// createBatchInEditor opens the batch buffer in the editor and creates the
+// issues in order, nesting issues under parents created in the same batch.
+// Issues that fail are opened in the editor again with the errors at the top,
+// until all issues are created or all summaries are emptied.
+func createBatchInEditor(c *jt.JiraClient, out *printer, ic jt.IssueConfig, opts jt.EditorOptions) error {
+	buf := jt.RenderBatchBuffer(ic, opts)
+	for {
+		edited, err := editBuffer(buf, opts)
+		if err != nil {
+			return err
+		}
+		buf = edited
+
+		issues, err := jt.ParseBatchBuffer(buf, ic, opts)
+		if errors.Is(err, jt.ErrEmptySummary) {
+			return err
+		}
+		if err != nil {
+			buf = jt.AnnotateBuffer(buf, err, opts)
+			continue
+		}
+
+		results := createBatch(c, issues)
+		if err := printBatchResults(c, out, results); err != nil {
+			return err
+		}
+
+		failed, errs := retryBatch(results)
+		if len(failed) == 0 {
+			return nil
+		}
+		buf = jt.AnnotateBuffer(jt.RenderBatchIssues(failed, opts), errors.Join(errs...), opts)
+	}
+}
+
+// createBatchFromBuffer creates the issues in the batch buffer without opening
+// the editor.
+func createBatchFromBuffer(c *jt.JiraClient, out *printer, ic jt.IssueConfig, buf string, opts jt.EditorOptions) error {
+	issues, err := jt.ParseBatchBuffer(buf, ic, opts)
+	if err != nil {
+		return err
+	}
+
+	results := createBatch(c, issues)
+	if err := printBatchResults(c, out, results); err != nil {
+		return err
+	}
+
+	if _, errs := retryBatch(results); len(errs) > 0 {
+		return fmt.Errorf("failed to create %d of %d issues", len(errs), len(results))
+	}
+	return nil
+}
+
+// createBatch creates the issues in order. Issues whose parent in the batch
+// failed are not created.
+func createBatch(c *jt.JiraClient, issues []jt.BatchIssue) []batchResult {
+	results := make([]batchResult, len(issues))
+	for i, issue := range issues {
+		results[i].issue = issue
+
+		ic := issue.IssueConfig
+		if issue.ParentRef > 0 {
+			parent := results[issue.ParentRef-1]
+			if parent.err != nil {
+				results[i].err = fmt.Errorf("parent #%d was not created", issue.ParentRef)
+				continue
+			}
+			ic.ParentIssueKey = parent.created.Key
+		}
+
+		results[i].created, results[i].err = c.NewJIRAIssue(ic)
+	}
+	return results
+}
+
+// retryBatch returns the issues that failed, with their parent references
+// updated to point to the created parents or the new positions in the batch,
+// and the errors prefixed with the issue they're for.
+func retryBatch(results []batchResult) ([]jt.BatchIssue, []error) {
+	var failed []jt.BatchIssue
+	var errs []error
+	// Positions of the failed issues in the new batch, by their old position.
+	positions := make(map[int]int)
+
+	for i, r := range results {
+		if r.err == nil {
+			continue
+		}
+
+		issue := r.issue
+		if issue.ParentRef > 0 {
+			if parent := results[issue.ParentRef-1]; parent.err == nil {
+				issue.ParentIssueKey = parent.created.Key
+				issue.ParentRef = 0
+			} else {
+				issue.ParentRef = positions[issue.ParentRef]
+			}
+		}
+
+		failed = append(failed, issue)
+		positions[i+1] = len(failed)
+		errs = append(errs, fmt.Errorf("issue %d %q: %w", len(failed), issue.Summary, r.err))
+	}
+	return failed, errs
+}
+
+// printBatchResults prints a table of the created and failed issues, or the
+// created issues in the output format, with the failures on stderr.
+func printBatchResults(c *jt.JiraClient, out *printer, results []batchResult) error {
+	if out.structured() {
+		var created []jt.Issue
+		for i, r := range results {
+			if r.err != nil {
+				fmt.Fprintf(os.Stderr, "issue %d %q: %s\n", i+1, r.issue.Summary, r.err)
+				continue
+			}
+			ic := r.issue.IssueConfig
+			if r.issue.ParentRef > 0 {
+				ic.ParentIssueKey = results[r.issue.ParentRef-1].created.Key
+			}
+			created = append(created, c.CreatedIssue(r.created, ic))
+		}
+		return out.issues(created, nil)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tKEY\tTYPE\tPARENT\tSUMMARY\tURL")
+	for i, r := range results {
+		parent := r.issue.ParentIssueKey
+		if r.issue.ParentRef > 0 {
+			parent = results[r.issue.ParentRef-1].created.Key
+			if parent == "" {
+				parent = "#" + strconv.Itoa(r.issue.ParentRef)
+			}
+		}
+
+		key, url := r.created.Key, issueURL(c, r.created.Key)
+		if r.err != nil {
+			key, url = "failed", r.err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, key, r.issue.IssueType, parent, r.issue.Summary, url)
+	}
+	return w.Flush()
+}
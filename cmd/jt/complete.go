@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leosunmo/jt"
+	"github.com/leosunmo/jt/jql"
+	"golang.org/x/term"
+)
+
+// autocompleteMaxAge is how long the JQL autocomplete data is cached for
+// before it's fetched from JIRA again.
+const autocompleteMaxAge = 24 * time.Hour
+
+// runComplete runs jt __complete, which the shell completion calls to
+// complete values that need data from JIRA:
+//
+//	jt __complete jql <partial query>
+//
+// It prints the completed value and its description, tab separated, one per
+// line. Nothing is printed if the completions can't be fetched, so the shell
+// isn't shown errors while completing.
+func runComplete(args []string) error {
+	if len(args) == 0 || args[0] != "jql" {
+		return nil
+	}
+	query := strings.Join(args[1:], " ")
+
+	conf, err := readConfig()
+	if err != nil {
+		return nil
+	}
+	out, err := newPrinter(conf)
+	if err != nil {
+		return nil
+	}
+	c, err := newClient(conf, out)
+	if err != nil {
+		return nil
+	}
+	completer, err := newCompleter(c, conf)
+	if err != nil {
+		return nil
+	}
+
+	start, suggestions, err := completer.Suggest(query)
+	if err != nil {
+		return nil
+	}
+	for _, s := range suggestions {
+		fmt.Printf("%s\t%s\n", query[:start]+s.Value, s.Description)
+	}
+	return nil
+}
+
+// newCompleter returns a JQL completer with the autocomplete data of the JIRA
+// instance, cached per instance in the cache directory.
+func newCompleter(c *jt.JiraClient, conf jt.JTConfig) (*jql.Completer, error) {
+	dir, err := jt.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL, %w", err)
+	}
+
+	path := filepath.Join(dir, "jql-autocomplete-"+u.Hostname()+".json")
+	data, err := jql.LoadAutocompleteData(path, autocompleteMaxAge, c.GetJQLAutocompleteData)
+	if err != nil {
+		return nil, err
+	}
+	return jql.NewCompleter(data, c.GetJQLSuggestions), nil
+}
+
+// promptJQL prompts for a JQL query in the terminal, completing the token
+// before the cursor when Tab is pressed. It returns an empty query if the
+// prompt is closed with Ctrl-C or Ctrl-D.
+func promptJQL(c *jt.JiraClient, conf jt.JTConfig) (string, error) {
+	completer, err := newCompleter(c, conf)
+	if err != nil {
+		return "", err
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "jql> ")
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		start, suggestions, err := completer.Suggest(line[:pos])
+		if err != nil || len(suggestions) == 0 {
+			return "", 0, false
+		}
+
+		insert := suggestions[0].Value
+		if len(suggestions) > 1 {
+			values := make([]string, 0, len(suggestions))
+			for _, s := range suggestions {
+				values = append(values, strings.TrimSpace(s.Value))
+				insert = commonPrefix(insert, s.Value)
+			}
+			fmt.Fprintln(t, strings.Join(values, "  "))
+			// Don't replace what's written with a shorter prefix of
+			// different case
+			if len(insert) <= pos-start {
+				return "", 0, false
+			}
+		}
+		return line[:start] + insert + line[pos:], start + len(insert), true
+	}
+
+	line, err := t.ReadLine()
+	if errors.Is(err, io.EOF) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read query: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// commonPrefix returns the longest prefix of a and b.
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/leosunmo/jt"
+	"github.com/spf13/pflag"
+)
+
+func runDrafts(args []string) error {
+	draftFlags := pflag.NewFlagSet("drafts", pflag.ContinueOnError)
+	draftFlags.Usage = func() {
+		fmt.Println("Usage: jt drafts list|resume|delete [id]")
+		fmt.Println("\nDrafts are issues that failed to be created from the editor.")
+		fmt.Println("\nCommands:")
+		fmt.Println("  list         List saved drafts")
+		fmt.Println("  resume <id>  Open the draft in the editor and create the issue")
+		fmt.Println("  delete <id>  Delete the draft")
+		fmt.Println("\nGlobal Flags:")
+		globalFlags.PrintDefaults()
+	}
+	draftFlags.AddFlagSet(globalFlags)
+
+	err := draftFlags.Parse(args)
+	if err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			draftFlags.Usage()
+			fmt.Printf("\n%s\n", err)
+		}
+		return nil
+	}
+
+	args = draftFlags.Args()
+	if len(args) == 0 {
+		draftFlags.Usage()
+		return nil
+	}
+
+	conf, err := readConfig()
+	if err != nil {
+		return err
+	}
+	opts := editorConfig(conf)
+
+	switch args[0] {
+	case "list", "ls":
+		drafts, err := jt.ListDrafts(opts)
+		if err != nil {
+			return err
+		}
+		if len(drafts) == 0 {
+			fmt.Println("no drafts")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSAVED\tSUMMARY")
+		for _, d := range drafts {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", d.ID, d.Saved.Format(time.DateTime), d.Summary)
+		}
+		return w.Flush()
+	case "resume":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: jt drafts resume <id>")
+		}
+		buf, err := jt.LoadDraft(args[1])
+		if err != nil {
+			return err
+		}
+		out, err := newPrinter(conf)
+		if err != nil {
+			return err
+		}
+		c, err := newClient(conf, out)
+		if err != nil {
+			return err
+		}
+		ic := jt.IssueConfig{
+			ProjectKey:     conf.DefaultProjectKey,
+			IssueType:      conf.DefaultIssueType,
+			ComponentNames: conf.DefaultComponentNames,
+		}
+		return createInEditor(c, out, ic, buf, opts, args[1])
+	case "delete", "rm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: jt drafts delete <id>")
+		}
+		return jt.RemoveDraft(args[1])
+	default:
+		return fmt.Errorf("unknown drafts command %q, expected list, resume or delete", args[0])
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leosunmo/jt"
+	"github.com/spf13/pflag"
+)
+
+func runImport(args []string) error {
+	importFlags := pflag.NewFlagSet("import", pflag.ContinueOnError)
+	importFlags.Usage = func() {
+		fmt.Println("Usage: jt import [flags] <file.csv|file.yaml|file.json>")
+		fmt.Println("\nCreate issues in bulk from a CSV, YAML or JSON file.")
+		fmt.Println("\nAvailable columns are id, summary, description, type, project, parent, assignee, labels, components")
+		fmt.Println("and customfield_XXXXX. The parent can be an issue key or the id of another row in the file.")
+		fmt.Println("\nGlobal Flags:")
+		globalFlags.PrintDefaults()
+	}
+	importFlags.AddFlagSet(globalFlags)
+
+	err := importFlags.Parse(args)
+	if err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			importFlags.Usage()
+			fmt.Printf("\n%s\n", err)
+		}
+		return nil
+	}
+
+	if importFlags.NArg() != 1 {
+		importFlags.Usage()
+		return nil
+	}
+
+	conf, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	rows, err := jt.ReadImportFile(importFlags.Arg(0), jt.IssueConfig{
+		ProjectKey:     conf.DefaultProjectKey,
+		IssueType:      conf.DefaultIssueType,
+		ComponentNames: conf.DefaultComponentNames,
+	})
+	if err != nil {
+		return err
+	}
+
+	groups, err := jt.ImportOrder(rows)
+	if err != nil {
+		return err
+	}
+
+	out, err := newPrinter(conf)
+	if err != nil {
+		return err
+	}
+
+	c, err := newClient(conf, out)
+	if err != nil {
+		return err
+	}
+
+	// Keys of the created issues by row ID, to set the parents of later rows.
+	keys := make(map[string]string)
+	results := make(map[int]jt.BulkCreateResult, len(rows))
+	// Issue configs of the created rows, with the parent keys set, for printing.
+	created := make(map[int]jt.IssueConfig, len(rows))
+
+	for _, group := range groups {
+		var confs []jt.IssueConfig
+		var pending []jt.ImportRow
+		for _, row := range group {
+			if row.ParentID != "" {
+				key, ok := keys[row.ParentID]
+				if !ok {
+					results[row.Row] = jt.BulkCreateResult{Err: fmt.Errorf("parent %q was not created", row.ParentID)}
+					continue
+				}
+				row.ParentIssueKey = key
+			}
+			confs = append(confs, row.IssueConfig)
+			pending = append(pending, row)
+		}
+
+		for i, res := range c.NewJIRAIssues(confs) {
+			row := pending[i]
+			results[row.Row] = res
+			created[row.Row] = row.IssueConfig
+			if res.Err == nil && row.ID != "" {
+				keys[row.ID] = res.Created.Key
+			}
+		}
+	}
+
+	if out.structured() {
+		err = printImportIssues(c, out, rows, results, created)
+	} else {
+		err = printImportResults(c, rows, results)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create %d of %d issues", failed, len(rows))
+	}
+	return nil
+}
+
+// printImportResults prints a table of the created and failed rows.
+func printImportResults(c *jt.JiraClient, rows []jt.ImportRow, results map[int]jt.BulkCreateResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROW\tID\tKEY\tSUMMARY\tURL")
+	for _, row := range rows {
+		res := results[row.Row]
+		key, url := res.Created.Key, issueURL(c, res.Created.Key)
+		if res.Err != nil {
+			key, url = "failed", res.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", row.Row, row.ID, key, row.Summary, url)
+	}
+	return w.Flush()
+}
+
+// printImportIssues prints the created issues in the output format, with the
+// failed rows on stderr.
+func printImportIssues(c *jt.JiraClient, out *printer, rows []jt.ImportRow, results map[int]jt.BulkCreateResult, created map[int]jt.IssueConfig) error {
+	var issues []jt.Issue
+	for _, row := range rows {
+		res := results[row.Row]
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "row %d %q: %s\n", row.Row, row.Summary, res.Err)
+			continue
+		}
+		issues = append(issues, c.CreatedIssue(res.Created, created[row.Row]))
+	}
+	return out.issues(issues, nil)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/leosunmo/jt"
+	"github.com/leosunmo/jt/jql"
+	"github.com/spf13/pflag"
+)
+
+func runJQL(args []string) error {
+	jqlFlags := pflag.NewFlagSet("jql", pflag.ContinueOnError)
+	jqlFlags.Usage = func() {
+		fmt.Println("Usage: jt jql fmt|lint [query]")
+		fmt.Println("\nThe query is read from stdin if it's not provided or is \"-\".")
+		fmt.Println("\nCommands:")
+		fmt.Println("  fmt   Print the query with normalized casing and quoting, split across lines if it's long")
+		fmt.Println("  lint  Check the query for unknown fields, text searches on fields that aren't text")
+		fmt.Println("        and queries that aren't limited to a project")
+		fmt.Println("\nGlobal Flags:")
+		globalFlags.PrintDefaults()
+	}
+	jqlFlags.AddFlagSet(globalFlags)
+
+	err := jqlFlags.Parse(args)
+	if err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			jqlFlags.Usage()
+			fmt.Printf("\n%s\n", err)
+		}
+		return nil
+	}
+
+	args = jqlFlags.Args()
+	if len(args) == 0 {
+		jqlFlags.Usage()
+		return nil
+	}
+	if args[0] != "fmt" && args[0] != "lint" {
+		return fmt.Errorf("unknown jql command %q, expected fmt or lint", args[0])
+	}
+
+	jqlString, err := readQuery(args[1:])
+	if err != nil {
+		return err
+	}
+	q, err := jql.Parse(jqlString)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "fmt" {
+		s, err := q.Format()
+		if err != nil {
+			return err
+		}
+		fmt.Println(s)
+		return nil
+	}
+
+	conf, err := readConfig()
+	if err != nil {
+		return err
+	}
+	out, err := newPrinter(conf)
+	if err != nil {
+		return err
+	}
+	c, err := newClient(conf, out)
+	if err != nil {
+		return err
+	}
+	fields, err := c.GetFields()
+	if err != nil {
+		return fmt.Errorf("failed to get fields: %w", err)
+	}
+
+	warnings, err := q.Lint(lintFields(fields))
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Println(w)
+	}
+	if len(warnings) > 0 {
+		return fmt.Errorf("found %d problems in the query", len(warnings))
+	}
+	return nil
+}
+
+// readQuery returns the query from the arguments, or stdin if there are none
+// or the only argument is "-".
+func readQuery(args []string) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read query from stdin: %w", err)
+	}
+	return string(b), nil
+}
+
+// lintFields returns the fields of the JIRA instance for linting queries.
+func lintFields(fields []jt.JiraField) []jql.FieldInfo {
+	info := make([]jql.FieldInfo, 0, len(fields))
+	for _, f := range fields {
+		info = append(info, jql.FieldInfo{
+			Names: f.ClauseNames,
+			Text:  textField(f),
+		})
+	}
+	return info
+}
+
+// textField reports whether the field can be searched with ~, which only
+// works on text fields.
+func textField(f jt.JiraField) bool {
+	switch f.Schema.System {
+	case "summary", "description", "environment", "comment":
+		return true
+	}
+	for _, t := range []string{":textfield", ":textarea", ":readonlyfield"} {
+		if strings.HasSuffix(f.Schema.Custom, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strings"
@@ -12,16 +13,29 @@ import (
 )
 
 var (
+	globalFlags    = pflag.NewFlagSet("global", pflag.ContinueOnError)
 	issueFlags     = pflag.NewFlagSet("issues", pflag.ContinueOnError)
 	exclusiveFlags = pflag.NewFlagSet("exclusive", pflag.ContinueOnError)
-	msg            = issueFlags.StringP("msg", "m", "", "Issue description, optional")
+	msg            = issueFlags.StringP("msg", "m", "", `Issue description, "-" to read it from stdin, optional`)
 	edit           = issueFlags.BoolP("edit", "e", false, "Open default editor for summary and description, optional")
 	parent         = issueFlags.StringP("parent", "p", "", "Assign the issue to a parent Epic or Initiative, optional")
+	templateName   = issueFlags.StringP("template", "t", "", "Pre-fill the issue from a named template in the config or templates directory, optional")
+	issueType      = issueFlags.String("type", "", "Issue type, defaults to defaultIssueType from the config, optional")
+	assignee       = issueFlags.StringP("assignee", "a", "", `Assign the issue to a user. Can be "me", an account ID, a name or an email, optional`)
+	batch          = issueFlags.BoolP("batch", "b", false, "Create multiple issues from one editor session, optional")
+	fromFile       = issueFlags.StringP("from-file", "f", "", `Read the issue from a file in the editor format instead of opening the editor, "-" for stdin, optional`)
+	fromStdin      = issueFlags.Bool("stdin", false, "Read the issue from stdin in the editor format, same as --from-file -, optional")
 	query          = exclusiveFlags.StringSliceP("query", "q", []string{}, `Query issues and exit. Available queries are: "parents", "epics", "initiatives", "tasks", and "bugs".
 The "parents" query will search for parent issues (Epics, Initiatives by default).
 A wildcard text search term can also be provided after a comma.
 For example: jt -q "parents,some issue". Double quote if the search text contains spaces.`)
 	completion = exclusiveFlags.BoolP("completion", "c", false, "Print zsh shell completion script to stdout and exit")
+	configFile = globalFlags.String("config", "", `Path to the config file, optional.
+Defaults to $JT_CONFIG, $XDG_CONFIG_HOME/jt/config.yaml or the OS specific config directory`)
+	dryRun = globalFlags.Bool("dry-run", false, `Print the requests that would create or change issues instead of sending them.
+Read-only requests, like searches and looking up users, are still sent`)
+	output = globalFlags.StringP("output", "o", "", `Output format: text, json, yaml, table, tsv or template=<go template>, optional.
+Templates are executed for each issue, for example: -o 'template={{.Key}} {{.Fields.Summary}}'`)
 )
 
 func main() {
@@ -34,17 +48,43 @@ func main() {
 func run() error {
 	rootFlags := pflag.NewFlagSet("root", pflag.ContinueOnError)
 	rootFlags.Usage = func() {
-		fmt.Println("Usage: jt [flags] [summary]")
+		fmt.Println("Usage: jt [create] [flags] [summary]")
+		fmt.Println("       jt import [flags] <file.csv|file.yaml|file.json>")
+		fmt.Println("       jt query [flags] [name]")
+		fmt.Println("       jt drafts list|resume|delete [id]")
+		fmt.Println("       jt jql fmt|lint [query]")
 		fmt.Println("\nIf summary is not provided, jt will open your default editor and prompt you for a summary and description.")
 		fmt.Println("\nIssue Creation Flags:")
 		issueFlags.PrintDefaults()
 		fmt.Println("\nExclusive Flags:")
 		exclusiveFlags.PrintDefaults()
+		fmt.Println("\nGlobal Flags:")
+		globalFlags.PrintDefaults()
 	}
+	rootFlags.AddFlagSet(globalFlags)
 	rootFlags.AddFlagSet(issueFlags)
 	rootFlags.AddFlagSet(exclusiveFlags)
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "create":
+			// "create" is the default command, so it's optional.
+			args = args[1:]
+		case "import":
+			return runImport(args[1:])
+		case "query":
+			return runQueryCommand(args[1:])
+		case "drafts":
+			return runDrafts(args[1:])
+		case "jql":
+			return runJQL(args[1:])
+		case "__complete":
+			return runComplete(args[1:])
+		}
+	}
+
 	// Parse flags
-	err := rootFlags.Parse(os.Args[1:])
+	err := rootFlags.Parse(args)
 	if err != nil {
 		if !errors.Is(err, pflag.ErrHelp) {
 			rootFlags.Usage()
@@ -63,37 +103,222 @@ func run() error {
 		return runQuery(*query)
 	}
 
+	// Read the issue summary from the command line arguments.
+	summary := strings.Join(rootFlags.Args(), " ")
+
+	if *fromStdin {
+		if *fromFile != "" {
+			return fmt.Errorf("--stdin can't be used with --from-file")
+		}
+		*fromFile = "-"
+	}
+	if *fromFile != "" && (summary != "" || *msg != "" || *edit) {
+		return fmt.Errorf("summary, --msg and --edit can't be used when reading the issue from a file or stdin")
+	}
+	if *msg == "-" && *fromFile == "-" {
+		return fmt.Errorf("--msg - can't be used with --stdin")
+	}
+
+	// The editor needs a terminal, so don't try to open one in a pipeline.
+	needsEditor := *fromFile == "" && (summary == "" || *edit || *batch)
+	if needsEditor && (*msg == "-" || jt.CheckTerminal() != nil) {
+		return fmt.Errorf("%w, provide a summary or use --stdin or --from-file", jt.ErrNoTerminal)
+	}
+
 	var desc string
 	// Check if msg is set
-	if *msg != "" {
+	if *msg == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read description from stdin: %w", err)
+		}
+		desc = string(b)
+	} else if *msg != "" {
 		desc = *msg
 	}
 
-	// Read the issue summary from the command line arguments.
-	summary := strings.Join(rootFlags.Args(), " ")
+	conf, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	out, err := newPrinter(conf)
+	if err != nil {
+		return err
+	}
 
-	if summary == "" || *edit {
-		var err error
-		summary, desc, err = jt.OpenInEditor(summary, desc)
+	ic := jt.IssueConfig{
+		Summary:        summary,
+		Description:    desc,
+		ProjectKey:     conf.DefaultProjectKey,
+		IssueType:      conf.DefaultIssueType,
+		ComponentNames: conf.DefaultComponentNames,
+	}
+
+	if *templateName != "" {
+		tmpl, err := jt.LoadTemplate(conf, *templateName)
 		if err != nil {
 			return err
 		}
+		tmpl, err = tmpl.Render(jt.NewTemplateData(conf, summary))
+		if err != nil {
+			return err
+		}
+		tmpl.Apply(&ic)
 	}
 
-	// Get the token from the keyring.
-	t, err := jt.GetToken()
+	if parent != nil && *parent != "" {
+		ic.ParentIssueKey = *parent
+	}
+	if *issueType != "" {
+		ic.IssueType = *issueType
+	}
+	if *assignee != "" {
+		ic.Assignee = *assignee
+	}
+
+	c, err := newClient(conf, out)
 	if err != nil {
-		return fmt.Errorf("failed to get token: %s\n", err)
+		return err
+	}
+
+	var input string
+	if *fromFile != "" {
+		input, err = readInput(*fromFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *batch {
+		if summary != "" {
+			return fmt.Errorf("summary can't be provided with --batch")
+		}
+		if *fromFile != "" {
+			return createBatchFromBuffer(c, out, ic, input, editorConfig(conf))
+		}
+		return createBatchInEditor(c, out, ic, editorOptions(c, conf, ic.ProjectKey))
+	}
+
+	switch {
+	case *fromFile != "":
+		ic, err = jt.ParseBuffer(input, ic, editorConfig(conf))
+		if err != nil {
+			return err
+		}
+	case summary == "" || *edit:
+		opts := editorOptions(c, conf, ic.ProjectKey)
+		return createInEditor(c, out, ic, jt.RenderBuffer(ic, opts), opts, "")
 	}
 
-	conf, err := jt.ReadConfig(jt.DefaultConfigLocation)
+	created, err := c.NewJIRAIssue(ic)
 	if err != nil {
-		return fmt.Errorf("failed to read config: %s\n", err)
+		return fmt.Errorf("failed to create issue: %s\n", err)
+	}
+
+	return printCreated(c, out, ic, created)
+}
+
+// createInEditor opens the buffer in the editor and creates the issue. If JIRA
+// rejects the issue, the editor is opened again with the errors at the top
+// until the issue is created or the summary is emptied.
+// The buffer is saved as a draft while it fails, so it can be resumed later.
+// If draftID is set, that draft is updated, and removed once the issue is created.
+func createInEditor(c *jt.JiraClient, out *printer, ic jt.IssueConfig, buf string, opts jt.EditorOptions, draftID string) error {
+	for {
+		edited, err := editBuffer(buf, opts)
+		if err != nil {
+			return withDraftHint(err, draftID)
+		}
+		buf = edited
+
+		parsed, err := jt.ParseBuffer(buf, ic, opts)
+		if errors.Is(err, jt.ErrEmptySummary) {
+			return withDraftHint(err, draftID)
+		}
+		if err == nil {
+			var created jt.CreatedIssueResponse
+			created, err = c.NewJIRAIssue(parsed)
+			if err == nil {
+				// Nothing was sent in dry run mode, so keep the draft to send later
+				if draftID != "" && !c.DryRun() {
+					if err := jt.RemoveDraft(draftID); err != nil {
+						fmt.Fprintf(os.Stderr, "failed to remove draft: %s\n", err)
+					}
+				}
+				return printCreated(c, out, parsed, created)
+			}
+			err = fmt.Errorf("failed to create issue: %w", err)
+		}
+
+		d, saveErr := jt.SaveDraft(draftID, buf)
+		if saveErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to save draft: %s\n", saveErr)
+		} else {
+			draftID = d.ID
+		}
+		buf = jt.AnnotateBuffer(buf, err, opts)
+	}
+}
+
+// editBuffer opens the buffer in the editor. It's replaced in tests, which
+// don't have a terminal to open the editor in.
+var editBuffer = jt.EditBuffer
+
+// printCreated prints the created issue, or its key and URL in the text format.
+func printCreated(c *jt.JiraClient, out *printer, ic jt.IssueConfig, created jt.CreatedIssueResponse) error {
+	text := fmt.Sprintf("created issue: %s\tURL: %s", created.Key, c.IssueURL(created.Key))
+	if c.DryRun() {
+		text = "dry run, issue not created"
+	}
+	return out.issue(c.CreatedIssue(created, ic), text)
+}
+
+// issueURL returns the URL of the issue, or a note that it wasn't created in
+// dry run mode.
+func issueURL(c *jt.JiraClient, key string) string {
+	if c.DryRun() {
+		return "dry run, not created"
+	}
+	return c.IssueURL(key)
+}
+
+// readInput reads the file, or stdin if path is "-".
+func readInput(path string) (string, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read issue: %w", err)
+	}
+	return string(b), nil
+}
+
+// withDraftHint adds a hint on how to resume the draft to err, if there is one.
+func withDraftHint(err error, draftID string) error {
+	if draftID == "" {
+		return err
+	}
+	return fmt.Errorf("%w\ndraft %s saved, resume it with: jt drafts resume %s", err, draftID, draftID)
+}
+
+// newClient returns a JIRA client for the instance in the config, using the
+// token from the keyring. Dry run requests are printed to stderr if the output
+// is meant for other programs.
+func newClient(conf jt.JTConfig, out *printer) (*jt.JiraClient, error) {
+	// Get the token from the keyring.
+	t, err := jt.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %s\n", err)
 	}
 
 	parsedURL, err := url.Parse(conf.URL)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL, %w", err)
+		return nil, fmt.Errorf("failed to parse URL, %w", err)
 	}
 
 	jc := jt.JiraConfig{
@@ -101,25 +326,55 @@ func run() error {
 		Email: conf.Email,
 		Token: t,
 	}
+	if *dryRun {
+		jc.DryRun = os.Stdout
+		if out.structured() {
+			jc.DryRun = os.Stderr
+		}
+	}
 
-	ic := jt.IssueConfig{
-		Summary:        summary,
-		Description:    desc,
-		ProjectKey:     conf.DefaultProjectKey,
-		IssueType:      conf.DefaultIssueType,
-		ComponentNames: conf.DefaultComponentNames,
+	return jt.NewJiraClient(jc), nil
+}
+
+// editorOptions returns the editor options from the config, with the issue
+// types and components available in the project to list in the help text.
+// Failing to fetch them is not fatal, they're just not listed.
+func editorOptions(c *jt.JiraClient, conf jt.JTConfig, projectKey string) jt.EditorOptions {
+	opts := editorConfig(conf)
+
+	p, err := c.GetProject(projectKey)
+	if err != nil {
+		return opts
 	}
+	for _, it := range p.IssueTypes {
+		opts.IssueTypes = append(opts.IssueTypes, it.Name)
+	}
+	for _, comp := range p.Components {
+		opts.Components = append(opts.Components, comp.Name)
+	}
+	return opts
+}
 
-	if parent != nil && *parent != "" {
-		ic.ParentIssueKey = *parent
+// editorConfig returns the editor options set in the config.
+func editorConfig(conf jt.JTConfig) jt.EditorOptions {
+	return jt.EditorOptions{
+		CommentChar: conf.CommentChar,
+		Cleanup:     conf.EditorCleanup,
+		Command:     conf.Editor,
 	}
+}
 
-	c := jt.NewJiraClient(jc)
-	key, err := c.NewJIRAIssue(ic)
+// readConfig reads the config file from the location set by --config,
+// or the default location if it's not set.
+func readConfig() (jt.JTConfig, error) {
+	p, err := jt.ConfigPath(*configFile)
 	if err != nil {
-		return fmt.Errorf("failed to create issue: %s\n", err)
+		return jt.JTConfig{}, fmt.Errorf("failed to find config: %s\n", err)
 	}
 
-	fmt.Printf("created issue: %s\tURL: %s\n", key, parsedURL.String()+"/browse/"+key)
-	return nil
+	conf, err := jt.ReadConfig(p)
+	if err != nil {
+		return jt.JTConfig{}, fmt.Errorf("failed to read config: %s\n", err)
+	}
+	return conf, nil
 }
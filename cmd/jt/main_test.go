@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leosunmo/jt"
+)
+
+func TestCreateInEditorDryRunKeepsDraft(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s in dry run", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	buf := "Fix the build\n\nThe build is broken.\n"
+	d, err := jt.SaveDraft("", buf)
+	if err != nil {
+		t.Fatalf("failed to save draft: %s", err)
+	}
+
+	editBuffer = func(buf string, opts jt.EditorOptions) (string, error) { return buf, nil }
+	defer func() { editBuffer = jt.EditBuffer }()
+
+	var dryRun strings.Builder
+	c := jt.NewJiraClient(jt.JiraConfig{URL: srv.URL, DryRun: &dryRun})
+	out, err := newPrinter(jt.JTConfig{})
+	if err != nil {
+		t.Fatalf("failed to create printer: %s", err)
+	}
+	var b strings.Builder
+	out.w = &b
+
+	ic := jt.IssueConfig{ProjectKey: "ABC", IssueType: "Task"}
+	if err := createInEditor(c, out, ic, buf, jt.EditorOptions{}, d.ID); err != nil {
+		t.Fatalf("failed to create issue: %s", err)
+	}
+	if !strings.Contains(dryRun.String(), "Fix the build") {
+		t.Fatalf("expected the request to be printed, got %q", dryRun.String())
+	}
+	if _, err := jt.LoadDraft(d.ID); err != nil {
+		t.Fatalf("expected the draft to be kept in dry run, got %s", err)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/leosunmo/jt"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats for --output. The text format is the default and what the
+// shell completion expects.
+const (
+	outputText     = "text"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputTable    = "table"
+	outputTSV      = "tsv"
+	outputTemplate = "template"
+)
+
+// defaultTableFields are the table and tsv columns if tableFields isn't set in the config.
+var defaultTableFields = []string{"key", "type", "summary"}
+
+// issueColumn is a column of table and tsv output.
+type issueColumn struct {
+	// field is the issue field the column needs, if any.
+	field jt.Field
+	value func(p *printer, i jt.Issue) string
+}
+
+// issueColumns are the columns available in table and tsv output, besides
+// custom fields.
+var issueColumns = map[string]issueColumn{
+	"key":     {value: func(_ *printer, i jt.Issue) string { return i.Key }},
+	"url":     {value: func(p *printer, i jt.Issue) string { return p.baseURL + "/browse/" + i.Key }},
+	"type":    {jt.FieldIssuetype, func(_ *printer, i jt.Issue) string { return i.Fields.Issuetype.Name }},
+	"summary": {jt.FieldSummary, func(_ *printer, i jt.Issue) string { return i.Fields.Summary }},
+	"project": {jt.FieldProject, func(_ *printer, i jt.Issue) string { return i.Fields.Project.Key }},
+	"parent": {jt.FieldParent, func(_ *printer, i jt.Issue) string {
+		if i.Fields.Parent == nil {
+			return ""
+		}
+		return i.Fields.Parent.Key
+	}},
+	"labels": {jt.FieldLabels, func(_ *printer, i jt.Issue) string { return strings.Join(i.Fields.Labels, ",") }},
+	"components": {jt.FieldComponents, func(_ *printer, i jt.Issue) string {
+		names := make([]string, 0, len(i.Fields.Components))
+		for _, c := range i.Fields.Components {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, ",")
+	}},
+	"assignee": {jt.FieldAssignee, func(_ *printer, i jt.Issue) string { return userName(i.Fields.Assignee) }},
+	"reporter": {jt.FieldReporter, func(_ *printer, i jt.Issue) string { return userName(i.Fields.Reporter) }},
+	"status": {jt.FieldStatus, func(_ *printer, i jt.Issue) string {
+		if i.Fields.Status == nil {
+			return ""
+		}
+		return i.Fields.Status.Name
+	}},
+	"priority": {jt.FieldPriority, func(_ *printer, i jt.Issue) string {
+		if i.Fields.Priority == nil {
+			return ""
+		}
+		return i.Fields.Priority.Name
+	}},
+	"resolution": {jt.FieldResolution, func(_ *printer, i jt.Issue) string {
+		if i.Fields.Resolution == nil {
+			return ""
+		}
+		return i.Fields.Resolution.Name
+	}},
+	"created": {jt.FieldCreated, func(_ *printer, i jt.Issue) string { return formatTime(i.Fields.Created) }},
+	"updated": {jt.FieldUpdated, func(_ *printer, i jt.Issue) string { return formatTime(i.Fields.Updated) }},
+	"duedate": {jt.FieldDueDate, func(_ *printer, i jt.Issue) string { return i.Fields.DueDate }},
+}
+
+// lookupColumn returns the column with the name, which can also be a custom
+// field like "customfield_10016".
+func lookupColumn(name string) (issueColumn, error) {
+	if c, ok := issueColumns[name]; ok {
+		return c, nil
+	}
+	if strings.HasPrefix(name, jt.CustomFieldPrefix) {
+		return issueColumn{jt.Field(name), func(_ *printer, i jt.Issue) string {
+			return formatCustomField(i.Fields.CustomFields[name])
+		}}, nil
+	}
+	return issueColumn{}, fmt.Errorf("unknown column %q, expected one of %s or %sXXXXX",
+		name, strings.Join(slices.Sorted(maps.Keys(issueColumns)), ", "), jt.CustomFieldPrefix)
+}
+
+// columnFields returns the issue fields needed for the columns.
+func columnFields(columns []string) []jt.Field {
+	var fields []jt.Field
+	for _, name := range columns {
+		c, err := lookupColumn(name)
+		if err == nil && c.field != "" && !slices.Contains(fields, c.field) {
+			fields = append(fields, c.field)
+		}
+	}
+	return fields
+}
+
+func userName(u *jt.User) string {
+	if u == nil {
+		return ""
+	}
+	return u.DisplayName
+}
+
+// formatTime shortens JIRA timestamps to the minute, keeping anything it can't
+// parse as is.
+func formatTime(s string) string {
+	t, err := time.Parse(jt.TimeFormat, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// formatCustomField formats the value of a custom field. Options and users are
+// shown by name, anything else that isn't a plain value as JSON.
+func formatCustomField(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	case map[string]any:
+		for _, k := range []string{"value", "name", "displayName", "key"} {
+			if s, ok := v[k].(string); ok {
+				return s
+			}
+		}
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = formatCustomField(item)
+		}
+		return strings.Join(values, ",")
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// printer prints issues in the format chosen with --output.
+type printer struct {
+	w      io.Writer
+	format string
+	tmpl   *template.Template
+	// fields are the columns of table and tsv output.
+	fields  []string
+	baseURL string
+}
+
+// newPrinter returns a printer for the --output flag, with the table columns
+// from the config.
+func newPrinter(conf jt.JTConfig) (*printer, error) {
+	p := &printer{
+		w:       os.Stdout,
+		format:  *output,
+		fields:  conf.TableFields,
+		baseURL: strings.TrimSuffix(conf.URL, "/"),
+	}
+	if p.format == "" {
+		p.format = outputText
+	}
+	if len(p.fields) == 0 {
+		p.fields = defaultTableFields
+	}
+
+	if text, ok := strings.CutPrefix(p.format, outputTemplate+"="); ok {
+		tmpl, err := template.New("output").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse output template: %w", err)
+		}
+		p.format = outputTemplate
+		p.tmpl = tmpl
+	}
+
+	switch p.format {
+	case outputText, outputJSON, outputYAML, outputTemplate:
+	case outputTable, outputTSV:
+		for _, f := range p.fields {
+			if _, err := lookupColumn(f); err != nil {
+				return nil, fmt.Errorf("invalid tableFields: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected one of text, json, yaml, table, tsv or template=<go template>", *output)
+	}
+	return p, nil
+}
+
+// setColumns sets the table and tsv columns, and switches the text format to
+// a table if table is set.
+func (p *printer) setColumns(fields []string, table bool) error {
+	for _, f := range fields {
+		if _, err := lookupColumn(f); err != nil {
+			return err
+		}
+	}
+	p.fields = fields
+	if table && p.format == outputText {
+		p.format = outputTable
+	}
+	return nil
+}
+
+// structured reports whether the output is meant for other programs, in which
+// case anything else, such as dry run requests, should go to stderr.
+func (p *printer) structured() bool {
+	return p.format != outputText
+}
+
+// issue prints a single issue, using text for the text format.
+func (p *printer) issue(i jt.Issue, text string) error {
+	switch p.format {
+	case outputText:
+		_, err := fmt.Fprintln(p.w, text)
+		return err
+	case outputJSON, outputYAML:
+		return p.encode(i)
+	default:
+		return p.issues([]jt.Issue{i}, nil)
+	}
+}
+
+// issues prints a list of issues, using text to format each issue in the
+// text format.
+func (p *printer) issues(issues []jt.Issue, text func(jt.Issue) string) error {
+	switch p.format {
+	case outputText:
+		for _, i := range issues {
+			if _, err := fmt.Fprintln(p.w, text(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputJSON, outputYAML:
+		if issues == nil {
+			issues = []jt.Issue{}
+		}
+		return p.encode(issues)
+	case outputTable:
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(p.fields, "\t")))
+		for _, i := range issues {
+			fmt.Fprintln(w, strings.Join(p.row(i), "\t"))
+		}
+		return w.Flush()
+	case outputTSV:
+		for _, i := range issues {
+			if _, err := fmt.Fprintln(p.w, strings.Join(p.row(i), "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputTemplate:
+		for _, i := range issues {
+			var b strings.Builder
+			if err := p.tmpl.Execute(&b, i); err != nil {
+				return fmt.Errorf("failed to execute output template: %w", err)
+			}
+			s := b.String()
+			if !strings.HasSuffix(s, "\n") {
+				s += "\n"
+			}
+			if _, err := io.WriteString(p.w, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// streamIssues prints the issues of a search as they arrive in the formats
+// that print a line per issue. The table, JSON and YAML formats need all the
+// issues first, so they're collected and printed at the end.
+func (p *printer) streamIssues(issues iter.Seq2[jt.Issue, error], text func(jt.Issue) string) error {
+	var collected []jt.Issue
+	for i, err := range issues {
+		if err != nil {
+			return fmt.Errorf("failed to query issues: %w", err)
+		}
+		switch p.format {
+		case outputText, outputTSV, outputTemplate:
+			if err := p.issues([]jt.Issue{i}, text); err != nil {
+				return err
+			}
+		default:
+			collected = append(collected, i)
+		}
+	}
+
+	switch p.format {
+	case outputText, outputTSV, outputTemplate:
+		return nil
+	}
+	return p.issues(collected, text)
+}
+
+// row returns the table columns of the issue. Tabs and newlines are replaced
+// so they don't break the columns.
+func (p *printer) row(i jt.Issue) []string {
+	r := strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+	row := make([]string, len(p.fields))
+	for n, f := range p.fields {
+		c, _ := lookupColumn(f)
+		row[n] = r.Replace(c.value(p, i))
+	}
+	return row
+}
+
+// encode writes v as indented JSON or YAML.
+func (p *printer) encode(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output, %w", err)
+	}
+	if p.format == outputJSON {
+		_, err = fmt.Fprintf(p.w, "%s\n", b)
+		return err
+	}
+
+	// Go through the JSON so the YAML uses the same field names, decoding into
+	// a node to keep the order of the fields.
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return fmt.Errorf("failed to convert output to YAML, %w", err)
+	}
+	resetStyle(&node)
+	enc := yaml.NewEncoder(p.w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("failed to marshal output, %w", err)
+	}
+	return enc.Close()
+}
+
+// resetStyle clears the flow and quoting styles the node got from being
+// parsed as JSON, so it's written as regular block YAML.
+func resetStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/leosunmo/jt"
+)
+
+func TestPrinterIssues(t *testing.T) {
+	issues := []jt.Issue{
+		{Key: "ABC-1", Fields: jt.Fields{Summary: "First\tissue", Issuetype: jt.Issuetype{Name: "Task"}}},
+		{Key: "ABC-2", Fields: jt.Fields{Summary: "Second", Issuetype: jt.Issuetype{Name: "Bug"}, Labels: []string{"a", "b"}}},
+	}
+
+	testData := []struct {
+		name     string
+		output   string
+		fields   []string
+		expected string
+	}{
+		{
+			name:     "tsv",
+			output:   "tsv",
+			fields:   []string{"key", "labels", "summary"},
+			expected: "ABC-1\t\tFirst issue\nABC-2\ta,b\tSecond\n",
+		},
+		{
+			name:     "template",
+			output:   `template={{.Key}} {{join .Fields.Labels "+"}}`,
+			expected: "ABC-1 \nABC-2 a+b\n",
+		},
+		{
+			name:     "table",
+			output:   "table",
+			expected: "KEY    TYPE  SUMMARY\nABC-1  Task  First issue\nABC-2  Bug   Second\n",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			*output = tt.output
+			defer func() { *output = "" }()
+
+			p, err := newPrinter(jt.JTConfig{TableFields: tt.fields})
+			if err != nil {
+				t.Fatalf("failed to create printer: %s", err)
+			}
+			var b strings.Builder
+			p.w = &b
+			if err := p.issues(issues, nil); err != nil {
+				t.Fatalf("failed to print issues: %s", err)
+			}
+			if b.String() != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestPrinterYAML(t *testing.T) {
+	*output = "yaml"
+	defer func() { *output = "" }()
+
+	p, err := newPrinter(jt.JTConfig{})
+	if err != nil {
+		t.Fatalf("failed to create printer: %s", err)
+	}
+	var b strings.Builder
+	p.w = &b
+	if err := p.issue(jt.Issue{Key: "ABC-1", Fields: jt.Fields{Summary: "123"}}, ""); err != nil {
+		t.Fatalf("failed to print issue: %s", err)
+	}
+
+	// Fields keep the JSON order and names, and strings that look like
+	// numbers stay strings.
+	expected := "id: \"\"\nkey: ABC-1\nself: \"\"\nfields:\n"
+	if !strings.HasPrefix(b.String(), expected) || !strings.Contains(b.String(), `summary: "123"`) {
+		t.Fatalf("expected prefix %q and summary \"123\", got %q", expected, b.String())
+	}
+}
+
+func TestPrinterStreamIssues(t *testing.T) {
+	issues := func(yield func(jt.Issue, error) bool) {
+		if yield(jt.Issue{Key: "ABC-1"}, nil) {
+			yield(jt.Issue{}, errors.New("search request failed"))
+		}
+	}
+
+	for _, tt := range []struct {
+		output   string
+		expected string
+	}{
+		// Issues printed a line at a time are printed before the error
+		{output: "tsv", expected: "ABC-1\n"},
+		{output: "json", expected: ""},
+	} {
+		t.Run(tt.output, func(t *testing.T) {
+			*output = tt.output
+			defer func() { *output = "" }()
+
+			p, err := newPrinter(jt.JTConfig{TableFields: []string{"key"}})
+			if err != nil {
+				t.Fatalf("failed to create printer: %s", err)
+			}
+			var b strings.Builder
+			p.w = &b
+			err = p.streamIssues(issues, nil)
+			if err == nil || !strings.Contains(err.Error(), "search request failed") {
+				t.Fatalf("expected the search error, got %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, b.String())
+			}
+		})
+	}
+}
+
+func TestNewPrinterErrors(t *testing.T) {
+	defer func() { *output = "" }()
+
+	for _, o := range []string{"xml", "template={{.Key"} {
+		*output = o
+		if _, err := newPrinter(jt.JTConfig{}); err == nil {
+			t.Fatalf("expected an error for --output %q, got nil", o)
+		}
+	}
+	*output = "table"
+	if _, err := newPrinter(jt.JTConfig{TableFields: []string{"nope"}}); err == nil {
+		t.Fatalf("expected an error for an unknown table field, got nil")
+	}
+}
+
+func TestColumnValues(t *testing.T) {
+	var i jt.Issue
+	if err := json.Unmarshal([]byte(`{"key": "ABC-1", "fields": {
+		"status": {"name": "Done"},
+		"assignee": {"displayName": "Sam"},
+		"updated": "2024-10-01T12:34:56.000+0000",
+		"customfield_10016": 3,
+		"customfield_10030": [{"value": "Team A"}, {"value": "Team B"}]
+	}}`), &i); err != nil {
+		t.Fatalf("failed to unmarshal issue: %s", err)
+	}
+
+	*output = "tsv"
+	defer func() { *output = "" }()
+	p, err := newPrinter(jt.JTConfig{TableFields: []string{"key", "status", "assignee", "updated", "customfield_10016", "customfield_10030", "priority"}})
+	if err != nil {
+		t.Fatalf("failed to create printer: %s", err)
+	}
+
+	expected := []string{"ABC-1", "Done", "Sam", "2024-10-01 12:34", "3", "Team A,Team B", ""}
+	if got := p.row(i); !slices.Equal(got, expected) {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	fields := columnFields(p.fields)
+	expectedFields := []jt.Field{jt.FieldStatus, jt.FieldAssignee, jt.FieldUpdated, "customfield_10016", "customfield_10030", jt.FieldPriority}
+	if !slices.Equal(fields, expectedFields) {
+		t.Fatalf("expected %q, got %q", expectedFields, fields)
+	}
+}
@@ -1,118 +1,536 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
-	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/leosunmo/jt"
 	"github.com/leosunmo/jt/jql"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
+// builtinQueries are the queries that can be run by name with -q and jt query.
+var builtinQueries = []string{"parents", "epics", "initiatives", "tasks", "bugs"}
+
+// addBuiltinQuery adds the issue type condition of the named built-in query.
+// Any keyword joining it to earlier conditions must already be added.
+func addBuiltinQuery(qb *jql.JQLQueryBuilder, conf jt.JTConfig, name string) error {
+	if !slices.Contains(builtinQueries, name) {
+		return fmt.Errorf("unsupported query type: %s", name)
+	}
+
+	switch name {
+	case "parents":
+		// Query for parent issues (Epics, Initiatives by default)
+		if conf.DefaultParentIssueTypes != nil {
+			qb.In("type", conf.DefaultParentIssueTypes...)
+		} else {
+			qb.In("type", jt.IssueTypeEpic, jt.IssueTypeInitiative)
+		}
+	case "epics":
+		// Query for Epics and Initiatives
+		qb.Equals("type", jt.IssueTypeEpic)
+	case "initiatives":
+		// Query for Initiatives
+		qb.Equals("type", jt.IssueTypeInitiative)
+	case "tasks":
+		// Query for Tasks and Bugs
+		qb.Equals("type", jt.IssueTypeTask)
+	case "bugs":
+		// Query for Bugs
+		qb.Equals("type", jt.IssueTypeBug)
+	}
+	return nil
+}
+
 func runQuery(queryStrings []string) error {
 	// Split the queryStrings to get the query type from the first element
 	queryType := queryStrings[0]
 
-	t, err := jt.GetToken()
+	conf, err := readConfig()
 	if err != nil {
-		return fmt.Errorf("failed to get token: %s\n", err)
+		return err
 	}
 
-	conf, err := jt.ReadConfig(jt.DefaultConfigLocation)
+	out, err := newPrinter(conf)
 	if err != nil {
-		return fmt.Errorf("failed to read config: %s\n", err)
+		return err
 	}
 
-	parsedURL, err := url.Parse(conf.URL)
+	c, err := newClient(conf, out)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL, %w", err)
+		return err
 	}
 
-	jc := jt.JiraConfig{
-		URL:   parsedURL.String(),
-		Email: conf.Email,
-		Token: t,
-	}
-
-	c := jt.NewJiraClient(jc)
-
 	qb := jql.NewBuilder()
-	qb.
-		Equals("project", conf.DefaultProjectKey).
-		And().
-		In("component", conf.DefaultComponentNames...)
+	qb.Equals("project", conf.DefaultProjectKey)
+	if len(conf.DefaultComponentNames) > 0 {
+		qb.And().In("component", conf.DefaultComponentNames...)
+	}
 
 	// If a string is provided after the `,`, add it as a summary search term with a wildcard.
 	if len(queryStrings) > 1 {
 		qb.And().Contains("summary", queryStrings[1]+"*")
 	}
 
-	switch queryType {
-	case "parents":
-		// Query for parent issues (Epics, Initiatives by default)
-		if conf.DefaultParentIssueTypes != nil {
-			qb.And().In("type", conf.DefaultParentIssueTypes...)
-		} else {
-			qb.And().In("type", jt.IssueTypeEpic, jt.IssueTypeInitiative)
+	if err := addBuiltinQuery(qb.And(), conf, queryType); err != nil {
+		return err
+	}
+
+	issues, err := doQuery(c, qb, issueTypeOrder(conf), columnFields(out.fields), 0)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %s\n", queryType, err)
+	}
+	return out.issues(issues, issueText)
+}
+
+// issueText formats the issue for the text output of queries, which the shell
+// completion relies on.
+func issueText(issue jt.Issue) string {
+	return fmt.Sprintf("%s [%s]: %s", issue.Key, issue.Fields.Issuetype.Name, issue.Fields.Summary)
+}
+
+// runQueryCommand runs jt query, which searches with JQL, saved queries from
+// the config, or filters built from flags.
+func runQueryCommand(args []string) error {
+	queryFlags := pflag.NewFlagSet("query", pflag.ContinueOnError)
+	queryFlags.Usage = func() {
+		fmt.Println("Usage: jt query [flags] [name]")
+		fmt.Println("\nSearch for issues with JQL, a saved query, or filters built from the flags below.")
+		fmt.Println("Filters are scoped to the default project and components unless --project or --all-projects is set.")
+		fmt.Println("Flags are added to the filters of a saved query, replacing the ones it sets.")
+		fmt.Println("\nQueries:")
+		// The config is only read to list the saved queries, it's fine if it's missing.
+		conf, _ := readConfig()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, q := range queryList(conf) {
+			fmt.Fprintf(w, "  %s\t%s\n", q[0], q[1])
 		}
-		parents, err := doQuery(c, qb)
-		if err != nil {
-			return fmt.Errorf("failed to query parents: %s\n", err)
+		w.Flush()
+		fmt.Println("\nQuery Flags:")
+		queryFlags.PrintDefaults()
+		fmt.Println("\nGlobal Flags:")
+		globalFlags.PrintDefaults()
+	}
+	jqlString := queryFlags.String("jql", "", "Raw JQL query, checked for syntax errors before it's sent. Can't be combined with the filter flags")
+	statuses := queryFlags.StringSlice("status", nil, "Only issues with one of these statuses, comma separated")
+	assignee := queryFlags.String("assignee", "", `Only issues assigned to this user. Can be "me", an account ID, a name or an email`)
+	types := queryFlags.StringSlice("type", nil, "Only issues of these types, comma separated")
+	labels := queryFlags.StringSlice("label", nil, "Only issues with one of these labels, comma separated")
+	updatedSince := queryFlags.String("updated-since", "", `Only issues updated since a relative duration like "7d", "2w" or "12h", or a date like "2024-10-01"`)
+	projects := queryFlags.StringSlice("project", nil, "Only issues in these projects, instead of the default project and components")
+	allProjects := queryFlags.Bool("all-projects", false, "Don't scope the query to the default project and components")
+	fields := queryFlags.StringSlice("fields", nil, `Fields to show after the key and summary, comma separated. Prints a table unless --output is set.
+Available fields are type, status, assignee, reporter, priority, resolution, labels, components, parent, project,
+created, updated, duedate, url and custom fields like customfield_10016`)
+	orderBy := queryFlags.StringArray("order-by", nil, `Order the results by fields, as "field", "field:asc" or "field:desc". Can be repeated or comma separated.
+The results are sorted by issue type if not set`)
+	interactive := queryFlags.BoolP("interactive", "i", false, "Write the JQL query in a prompt that completes fields, operators, functions and values with Tab")
+	validate := queryFlags.Bool("validate", false, "Check the query with JIRA for unknown fields and values and exit without searching")
+	limit := queryFlags.Int("limit", 0, "Return at most this many issues")
+	list := queryFlags.Bool("list", false, "List the built-in and saved queries with their descriptions, tab separated")
+	queryFlags.AddFlagSet(globalFlags)
+
+	err := queryFlags.Parse(args)
+	if err != nil {
+		if !errors.Is(err, pflag.ErrHelp) {
+			queryFlags.Usage()
+			fmt.Printf("\n%s\n", err)
+		}
+		return nil
+	}
+	if queryFlags.NArg() > 1 {
+		queryFlags.Usage()
+		return nil
+	}
+	name := queryFlags.Arg(0)
+
+	conf, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		for _, q := range queryList(conf) {
+			fmt.Printf("%s\t%s\n", q[0], q[1])
 		}
-		for _, parent := range parents {
-			fmt.Printf("%s\n", parent)
+		return nil
+	}
+
+	// Saved queries take precedence over the built-in ones with the same name.
+	var q jt.SavedQuery
+	var builtin string
+	if saved, ok := conf.Queries[name]; ok {
+		q = saved
+	} else if name != "" {
+		if !slices.Contains(builtinQueries, name) {
+			return fmt.Errorf("unknown query %q, see jt query --list", name)
 		}
-	case "epics":
-		// Query for Epics and Initiatives
-		qb.And().Equals("type", jt.IssueTypeEpic)
-		e, err := doQuery(c, qb)
-		if err != nil {
-			return fmt.Errorf("failed to query epics: %s\n", err)
+		builtin = name
+	}
+
+	// Flags replace what the saved query sets.
+	if queryFlags.Changed("jql") {
+		q.JQL = *jqlString
+	}
+	if queryFlags.Changed("status") {
+		q.Statuses = *statuses
+	}
+	if queryFlags.Changed("assignee") {
+		q.Assignee = *assignee
+	}
+	if queryFlags.Changed("type") {
+		q.Types = *types
+	}
+	if queryFlags.Changed("label") {
+		q.Labels = *labels
+	}
+	if queryFlags.Changed("updated-since") {
+		q.UpdatedSince = *updatedSince
+	}
+	if queryFlags.Changed("project") {
+		q.Projects = *projects
+		q.AllProjects = false
+	}
+	if queryFlags.Changed("all-projects") {
+		q.AllProjects = *allProjects
+		if q.AllProjects {
+			q.Projects = nil
 		}
-		for _, epic := range e {
-			fmt.Printf("%s\n", epic)
+	}
+
+	if queryFlags.Changed("order-by") {
+		q.OrderBy = *orderBy
+	}
+	if queryFlags.Changed("limit") {
+		if *limit < 1 {
+			return fmt.Errorf("--limit must be at least 1")
 		}
-	case "initiatives":
-		// Query for Initiatives
-		qb.And().Equals("type", jt.IssueTypeInitiative)
-		initiatives, err := doQuery(c, qb)
+		q.Limit = *limit
+	}
+	if queryFlags.Changed("fields") {
+		q.Columns = []string{"key", "summary"}
+		for _, f := range *fields {
+			if !slices.Contains(q.Columns, f) {
+				q.Columns = append(q.Columns, f)
+			}
+		}
+	}
+
+	if *interactive && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("--interactive needs a terminal")
+	}
+
+	if err := checkQuery(q, builtin, *interactive); err != nil {
+		if name != "" && builtin == "" {
+			return fmt.Errorf("query %q: %w", name, err)
+		}
+		return err
+	}
+
+	out, err := newPrinter(conf)
+	if err != nil {
+		return err
+	}
+	if len(q.Columns) > 0 {
+		if err := out.setColumns(q.Columns, !queryFlags.Changed("output")); err != nil {
+			return err
+		}
+	}
+
+	c, err := newClient(conf, out)
+	if err != nil {
+		return err
+	}
+
+	if *interactive {
+		q.JQL, err = promptJQL(c, conf)
+		if err != nil || q.JQL == "" {
+			return err
+		}
+	}
+
+	qb, err := buildQuery(c, conf, q, builtin)
+	if err != nil {
+		return err
+	}
+
+	// JIRA finds mistakes the parser can't, like unknown fields. Saved queries
+	// are checked before searching so the errors point into the saved JQL.
+	saved := name != "" && builtin == ""
+	if *validate || (saved && q.JQL != "") {
+		err := validateQuery(c, qb, q.JQL)
+		if err != nil && saved {
+			err = fmt.Errorf("query %q: %w", name, err)
+		}
+		if err != nil || *validate {
+			return err
+		}
+	}
+
+	// Only sort by issue type if the query isn't ordered already.
+	var typeOrder []string
+	if !qb.Ordered() && q.Filter == "" {
+		typeOrder = issueTypeOrder(conf)
+	}
+
+	// Issues that don't need sorting are printed as the pages arrive.
+	if len(typeOrder) == 0 {
+		req, err := searchRequest(qb, columnFields(out.fields), q.Limit)
 		if err != nil {
-			return fmt.Errorf("failed to query initiatives: %s\n", err)
+			return err
 		}
-		for _, initiative := range initiatives {
-			fmt.Printf("%s\n", initiative)
+		return out.streamIssues(c.SearchIssues(req), issueText)
+	}
+
+	issues, err := doQuery(c, qb, typeOrder, columnFields(out.fields), q.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to query issues: %s\n", err)
+	}
+	return out.issues(issues, issueText)
+}
+
+// validateQuery checks the query with JIRA. Raw JQL is sent as it was written,
+// rather than as the builder rebuilt it, so the errors point at the right
+// line and column of it.
+func validateQuery(c *jt.JiraClient, qb *jql.JQLQueryBuilder, rawJQL string) error {
+	// Syntax errors are found without asking JIRA
+	built, err := qb.Build()
+	if err != nil {
+		return err
+	}
+	return c.ValidateJQL(cmp.Or(rawJQL, built))
+}
+
+// queryList returns the names and descriptions of the built-in and saved
+// queries, sorted by name.
+func queryList(conf jt.JTConfig) [][2]string {
+	var queries [][2]string
+	for _, name := range builtinQueries {
+		if _, ok := conf.Queries[name]; !ok {
+			queries = append(queries, [2]string{name, "Built-in " + name + " query"})
 		}
-	case "tasks":
-		// Query for Tasks and Bugs
-		qb.And().Equals("type", jt.IssueTypeTask)
-		tasks, err := doQuery(c, qb)
+	}
+	for name, q := range conf.Queries {
+		queries = append(queries, [2]string{name, q.Description})
+	}
+	slices.SortFunc(queries, func(a, b [2]string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	return queries
+}
+
+// checkQuery returns an error if the query mixes raw JQL, a saved filter and
+// filters, which can't be combined. interactive is set if the JQL is going to
+// be prompted for, which is checked like raw JQL before prompting.
+func checkQuery(q jt.SavedQuery, builtin string, interactive bool) error {
+	var filters []string
+	if len(q.Statuses) > 0 {
+		filters = append(filters, "status")
+	}
+	if q.Assignee != "" {
+		filters = append(filters, "assignee")
+	}
+	if len(q.Types) > 0 {
+		filters = append(filters, "type")
+	}
+	if len(q.Labels) > 0 {
+		filters = append(filters, "label")
+	}
+	if q.UpdatedSince != "" {
+		filters = append(filters, "updated-since")
+	}
+	if len(q.Projects) > 0 {
+		filters = append(filters, "project")
+	}
+	if q.AllProjects {
+		filters = append(filters, "all-projects")
+	}
+
+	for _, raw := range []struct {
+		name string
+		set  bool
+	}{{"jql", q.JQL != ""}, {"filter", q.Filter != ""}, {"--interactive", interactive}} {
+		if !raw.set {
+			continue
+		}
+		if len(filters) > 0 {
+			return fmt.Errorf("%s can't be combined with %s", raw.name, strings.Join(filters, ", "))
+		}
+		if builtin != "" {
+			return fmt.Errorf("%s can't be combined with the %q query", raw.name, builtin)
+		}
+		if len(q.OrderBy) > 0 {
+			return fmt.Errorf("%s can't be combined with orderBy, add ORDER BY to the JQL instead", raw.name)
+		}
+	}
+	if q.JQL != "" && q.Filter != "" {
+		return fmt.Errorf("jql can't be combined with filter")
+	}
+	if interactive && (q.JQL != "" || q.Filter != "") {
+		return fmt.Errorf("--interactive can't be combined with jql or filter")
+	}
+	if len(q.Projects) > 0 && q.AllProjects {
+		return fmt.Errorf("all-projects can't be combined with project")
+	}
+	return nil
+}
+
+// buildQuery returns the query builder for the query, fetching the JQL of
+// saved filters and looking up the assignee, unless it's "me".
+func buildQuery(c *jt.JiraClient, conf jt.JTConfig, q jt.SavedQuery, builtin string) (*jql.JQLQueryBuilder, error) {
+	qb := jql.NewBuilder()
+
+	if q.Filter != "" {
+		f, err := c.GetFilter(q.Filter)
 		if err != nil {
-			return fmt.Errorf("failed to query tasks: %s\n", err)
+			return nil, fmt.Errorf("failed to get filter %s: %w", q.Filter, err)
 		}
-		for _, task := range tasks {
-			fmt.Printf("%s\n", task)
+		return qb.SetJQLString(f.JQL), nil
+	}
+	if q.JQL != "" {
+		return qb.SetJQLString(q.JQL), nil
+	}
+
+	var since string
+	if q.UpdatedSince != "" {
+		var err error
+		since, err = relativeDate(q.UpdatedSince)
+		if err != nil {
+			return nil, err
 		}
-	case "bugs":
-		// Query for Bugs
-		qb.And().Equals("type", jt.IssueTypeBug)
-		bugs, err := doQuery(c, qb)
+	}
+	order, err := parseOrderBy(q.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// and adds an AND before every condition but the first.
+	n := 0
+	and := func() *jql.JQLQueryBuilder {
+		if n > 0 {
+			qb.And()
+		}
+		n++
+		return qb
+	}
+
+	switch {
+	case len(q.Projects) > 0:
+		and().In("project", q.Projects...)
+	case !q.AllProjects:
+		and().Equals("project", conf.DefaultProjectKey)
+		if len(conf.DefaultComponentNames) > 0 {
+			and().In("component", conf.DefaultComponentNames...)
+		}
+	}
+	if len(q.Statuses) > 0 {
+		and().In("status", q.Statuses...)
+	}
+	if len(q.Types) > 0 {
+		and().In("type", q.Types...)
+	}
+	if len(q.Labels) > 0 {
+		and().In("labels", q.Labels...)
+	}
+	switch {
+	case strings.EqualFold(q.Assignee, "me"):
+		// Saves looking up the current user.
+		and().Cond("assignee", "=", jql.CurrentUser())
+	case q.Assignee != "":
+		id, err := c.FindAccountID(q.Assignee)
 		if err != nil {
-			return fmt.Errorf("failed to query bugs: %s\n", err)
+			return nil, fmt.Errorf("failed to find assignee: %w", err)
 		}
-		for _, bug := range bugs {
-			fmt.Printf("%s\n", bug)
+		and().Equals("assignee", id)
+	}
+	switch {
+	case relativeDateRe.MatchString(since):
+		and().Cond("updated", ">=", jql.RelativeDate(since))
+	case since != "":
+		and().GreaterThanOrEquals("updated", since)
+	}
+	if builtin != "" {
+		if err := addBuiltinQuery(and(), conf, builtin); err != nil {
+			return nil, err
 		}
-	default:
-		return fmt.Errorf("unsupported query type: %s", queryType)
 	}
-	return nil
+	if n == 0 {
+		return nil, fmt.Errorf("no filters provided, use --jql or at least one filter with --all-projects")
+	}
+	for _, o := range order {
+		qb.OrderBy(o.ascending, o.field)
+	}
+	return qb, nil
 }
 
-func doQuery(c *jt.JiraClient, qb *jql.JQLQueryBuilder) ([]string, error) {
-	q, err := qb.Build()
+// orderBy is a field to order query results by.
+type orderBy struct {
+	field     string
+	ascending bool
+}
 
+// parseOrderBy parses "field" and "field:asc|desc", optionally comma separated,
+// into the fields to order by. Fields without a direction are ordered ascending.
+func parseOrderBy(specs []string) ([]orderBy, error) {
+	var order []orderBy
+	for _, list := range specs {
+		for _, spec := range strings.Split(list, ",") {
+			field, dir, _ := strings.Cut(strings.TrimSpace(spec), ":")
+			o := orderBy{field: field, ascending: true}
+			switch strings.ToLower(dir) {
+			case "", "asc":
+			case "desc":
+				o.ascending = false
+			default:
+				return nil, fmt.Errorf("invalid order %q, expected field, field:asc or field:desc", spec)
+			}
+			if field == "" {
+				return nil, fmt.Errorf("invalid order %q, missing field", spec)
+			}
+			order = append(order, o)
+		}
+	}
+	return order, nil
+}
+
+// relativeDateRe matches JQL relative dates, with or without the leading "-".
+var relativeDateRe = regexp.MustCompile(`^-?\d+[wdhm]$`)
+
+// relativeDate returns s as a JQL date, turning durations like "7d" into
+// relative dates in the past like "-7d".
+func relativeDate(s string) (string, error) {
+	if relativeDateRe.MatchString(s) {
+		return "-" + strings.TrimPrefix(s, "-"), nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04", "2006/01/02", "2006/01/02 15:04"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q, expected a duration like 7d, 2w or 12h, or a date like 2024-10-01", s)
+}
+
+// defaultIssueTypeOrder is the order of query results if issueTypeOrder
+// isn't set in the config.
+var defaultIssueTypeOrder = []string{jt.IssueTypeInitiative, jt.IssueTypeEpic, jt.IssueTypeStory, jt.IssueTypeTask}
+
+// searchRequest returns the search for the query, requesting the fields in
+// addition to the summary, type and components, and at most limit issues if
+// it's set.
+func searchRequest(qb *jql.JQLQueryBuilder, fields []jt.Field, limit int) (jt.JQLSearchRequest, error) {
+	q, err := qb.Build()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build query: %s\n", err)
+		return jt.JQLSearchRequest{}, fmt.Errorf("failed to build query: %s\n", err)
 	}
 
 	queryReq := jt.JQLSearchRequest{
@@ -122,28 +540,51 @@ func doQuery(c *jt.JiraClient, qb *jql.JQLQueryBuilder) ([]string, error) {
 			jt.FieldIssuetype,
 			jt.FieldSummary,
 		},
+		Limit: limit,
+	}
+	for _, f := range fields {
+		if !slices.Contains(queryReq.IncludedFields, f) {
+			queryReq.IncludedFields = append(queryReq.IncludedFields, f)
+		}
+	}
+	return queryReq, nil
+}
+
+// issueTypeOrder returns the order to sort query results by issue type in.
+func issueTypeOrder(conf jt.JTConfig) []string {
+	if conf.IssueTypeOrder == nil {
+		return defaultIssueTypeOrder
+	}
+	return conf.IssueTypeOrder
+}
+
+// doQuery runs the query, requesting the fields in addition to the summary,
+// type and components, and returning at most limit issues if it's set.
+// The issues are sorted by type in typeOrder, if it's set.
+func doQuery(c *jt.JiraClient, qb *jql.JQLQueryBuilder, typeOrder []string, fields []jt.Field, limit int) ([]jt.Issue, error) {
+	queryReq, err := searchRequest(qb, fields, limit)
+	if err != nil {
+		return nil, err
 	}
 
 	issues, err := c.SearchJiraIssues(queryReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query issues: %s\n", err)
 	}
-	// Sort issues by type: Initiatives first, then Epics, then Stories, lastly Tasks
-	sortOrder := map[string]int{
-		jt.IssueTypeInitiative: 1,
-		jt.IssueTypeEpic:       2,
-		jt.IssueTypeStory:      3,
-		jt.IssueTypeTask:       4,
+	if len(typeOrder) == 0 {
+		return issues, nil
+	}
+
+	// Sort issues by type in the configured order, Initiatives first, then
+	// Epics, then Stories, lastly Tasks by default.
+	sortOrder := make(map[string]int, len(typeOrder))
+	for i, t := range typeOrder {
+		sortOrder[t] = i + 1
 	}
 
 	sort.SliceStable(issues, func(i, j int) bool {
 		return sortOrder[issues[i].Fields.Issuetype.Name] < sortOrder[issues[j].Fields.Issuetype.Name]
 	})
 
-	output := make([]string, 0, len(issues))
-
-	for _, issue := range issues {
-		output = append(output, fmt.Sprintf("%s [%s]: %s", issue.Key, issue.Fields.Issuetype.Name, issue.Fields.Summary))
-	}
-	return output, nil
+	return issues, nil
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/leosunmo/jt"
+)
+
+func TestRelativeDate(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected string
+		errMsg   string
+	}{
+		{in: "7d", expected: "-7d"},
+		{in: "-2w", expected: "-2w"},
+		{in: "12h", expected: "-12h"},
+		{in: "2024-10-01", expected: "2024-10-01"},
+		{in: "2024/10/01 09:30", expected: "2024/10/01 09:30"},
+		{in: "yesterday", errMsg: `invalid date "yesterday"`},
+		{in: "7x", errMsg: `invalid date "7x"`},
+	}
+
+	for _, tt := range testData {
+		got, err := relativeDate(tt.in)
+		if tt.errMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %v", tt.errMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to parse date %q: %s", tt.in, err)
+		}
+		if got != tt.expected {
+			t.Fatalf("expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	order, err := parseOrderBy([]string{"priority:DESC", "created, updated:desc"})
+	if err != nil {
+		t.Fatalf("failed to parse order: %s", err)
+	}
+	expected := []orderBy{{field: "priority", ascending: false}, {field: "created", ascending: true}, {field: "updated", ascending: false}}
+	if !slices.Equal(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+
+	for _, specs := range [][]string{{"priority:up"}, {":desc"}, {"priority,"}} {
+		if _, err := parseOrderBy(specs); err == nil {
+			t.Fatalf("expected an error for %q, got nil", specs)
+		}
+	}
+}
+
+func TestCheckQuery(t *testing.T) {
+	testData := []struct {
+		name        string
+		q           jt.SavedQuery
+		builtin     string
+		interactive bool
+		errMsg      string
+	}{
+		{name: "filters", q: jt.SavedQuery{Statuses: []string{"Open"}, OrderBy: []string{"created"}}, builtin: "bugs"},
+		{name: "jql", q: jt.SavedQuery{JQL: "project = ABC", Columns: []string{"key"}}},
+		{name: "jql and filters", q: jt.SavedQuery{JQL: "project = ABC", Labels: []string{"x"}}, errMsg: "jql can't be combined with label"},
+		{name: "filter and builtin", q: jt.SavedQuery{Filter: "10042"}, builtin: "epics", errMsg: `filter can't be combined with the "epics" query`},
+		{name: "jql and order", q: jt.SavedQuery{JQL: "project = ABC", OrderBy: []string{"created"}}, errMsg: "orderBy"},
+		{name: "jql and filter", q: jt.SavedQuery{JQL: "project = ABC", Filter: "10042"}, errMsg: "jql can't be combined with filter"},
+		{name: "interactive", q: jt.SavedQuery{Columns: []string{"key"}}, interactive: true},
+		{name: "interactive and filters", q: jt.SavedQuery{Statuses: []string{"Open"}}, interactive: true, errMsg: "--interactive can't be combined with status"},
+		{name: "interactive and jql", q: jt.SavedQuery{JQL: "project = ABC"}, interactive: true, errMsg: "--interactive can't be combined with jql"},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuery(tt.q, tt.builtin, tt.interactive)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
@@ -1,17 +1,29 @@
 package jt
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 const (
+	// DefaultConfigLocation is the config location used when no other location
+	// has been configured and the OS has no better default.
 	DefaultConfigLocation = "~/.config/jt/config.yaml"
+
+	// ConfigEnvVar is the environment variable that can be used to point jt at
+	// a specific config file.
+	ConfigEnvVar = "JT_CONFIG"
+
+	configDirName    = "jt"
+	configFileName   = "config.yaml"
+	templatesDirName = "templates"
 )
 
 type JTConfig struct {
@@ -27,13 +39,42 @@ type JTConfig struct {
 	DefaultComponentNames []string `yaml:"defaultComponentNames"`
 	// Default parent issue types are the issue types that will be searched for when querying for parent issues.
 	DefaultParentIssueTypes []string `yaml:"defaultParentIssueTypes"`
+	// Templates are named issue templates, used with --template.
+	Templates map[string]IssueTemplate `yaml:"templates"`
+	// TemplatesDir is a directory of <name>.yaml issue templates.
+	// Defaults to the "templates" directory next to the config file.
+	TemplatesDir string `yaml:"templatesDir"`
+	// CommentChar starts comment lines in the editor buffer. Defaults to "#".
+	CommentChar string `yaml:"commentChar"`
+	// EditorCleanup is how comment lines are removed from the editor buffer,
+	// "scissors" (default) or "strip". See CleanupScissors and CleanupStrip.
+	EditorCleanup string `yaml:"editorCleanup"`
+	// Editor is the editor command, including any arguments. Example: "code --wait".
+	// $JT_EDITOR takes precedence, while $VISUAL and $EDITOR are only used if it's not set.
+	Editor string `yaml:"editor"`
+	// TableFields are the columns of --output table and tsv.
+	// Defaults to key, type and summary.
+	TableFields []string `yaml:"tableFields"`
+	// IssueTypeOrder sorts query results by issue type, in this order, unless
+	// the query is ordered another way. Types that aren't listed come first.
+	// Defaults to Initiative, Epic, Story and Task. Set to [] to keep the order
+	// from JIRA.
+	IssueTypeOrder []string `yaml:"issueTypeOrder"`
+	// Queries are named queries, run with "jt query <name>".
+	Queries map[string]SavedQuery `yaml:"queries"`
 }
 
-// ReadConfig reads config file from the default location.
+// ReadConfig reads config file from the provided location.
+// Use ConfigPath to find the location of the config file.
 func ReadConfig(configPath string) (JTConfig, error) {
 	c := JTConfig{}
 
-	f, err := os.Open(expandPath(configPath))
+	p, err := expandPath(configPath)
+	if err != nil {
+		return c, err
+	}
+
+	f, err := os.Open(p)
 	if err != nil {
 		return c, fmt.Errorf("failed to open config file: %w", err)
 	}
@@ -44,16 +85,119 @@ func ReadConfig(configPath string) (JTConfig, error) {
 		return c, fmt.Errorf("failed to decode config file: %w", err)
 	}
 
+	if c.TemplatesDir == "" {
+		c.TemplatesDir = filepath.Join(filepath.Dir(p), templatesDirName)
+	}
+
 	return c, nil
 }
 
-func expandPath(path string) string {
-	usr, _ := user.Current()
-	dir := usr.HomeDir
-	if strings.HasPrefix(path, "~/") {
-		// Use strings.HasPrefix so we don't match paths like
-		// "/something/~/something/"
-		return filepath.Join(dir, path[2:])
+// ConfigPath returns the location of the config file.
+// The first of the following that is set wins:
+//   - the provided path, usually from the --config flag
+//   - $JT_CONFIG
+//   - $XDG_CONFIG_HOME/jt/config.yaml
+//   - the OS specific default, %AppData%\jt\config.yaml on Windows and
+//     ~/.config/jt/config.yaml everywhere else. On macOS,
+//     ~/Library/Application Support/jt/config.yaml is used if it exists
+//     and ~/.config/jt/config.yaml does not.
+func ConfigPath(path string) (string, error) {
+	if path != "" {
+		return expandPath(path)
+	}
+
+	if p := os.Getenv(ConfigEnvVar); p != "" {
+		return expandPath(p)
+	}
+
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// ConfigDir returns the directory jt keeps its configuration in.
+// See ConfigPath for the lookup order, minus the config file overrides.
+func ConfigDir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, configDirName), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine config directory: %w", err)
+		}
+		return filepath.Join(dir, configDirName), nil
+	case "darwin":
+		def, err := expandPath(filepath.Dir(DefaultConfigLocation))
+		if err != nil {
+			return "", err
+		}
+		if exists(filepath.Join(def, configFileName)) {
+			return def, nil
+		}
+		// Fall back to the native location if there's a config file there.
+		if dir, err := os.UserConfigDir(); err == nil && exists(filepath.Join(dir, configDirName, configFileName)) {
+			return filepath.Join(dir, configDirName), nil
+		}
+		return def, nil
+	default:
+		return expandPath(filepath.Dir(DefaultConfigLocation))
+	}
+}
+
+// CacheDir returns the directory jt caches data from JIRA in.
+// $XDG_CACHE_HOME/jt is used if set, otherwise the OS specific cache
+// directory, like ~/.cache/jt on Linux and ~/Library/Caches/jt on macOS.
+func CacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, configDirName), nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, configDirName), nil
+}
+
+// expandPath expands a leading "~" in path to the current user's home directory.
+func expandPath(path string) (string, error) {
+	// Use strings.HasPrefix so we don't match paths like
+	// "/something/~/something/"
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	dir, err := homeDir()
+	if err != nil {
+		return "", err
 	}
-	return path
+	return filepath.Join(dir, strings.TrimPrefix(path[1:], "/")), nil
+}
+
+// homeDir returns the home directory of the current user.
+// $HOME (or the OS equivalent) is preferred, falling back on the user database
+// for environments where it's not set, such as some containers.
+func homeDir() (string, error) {
+	if dir, err := os.UserHomeDir(); err == nil && dir != "" {
+		return dir, nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	if usr.HomeDir == "" {
+		return "", errors.New("failed to determine home directory: no home directory set for current user")
+	}
+	return usr.HomeDir, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
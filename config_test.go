@@ -0,0 +1,110 @@
+package jt
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConfigPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	testData := []struct {
+		name     string
+		path     string
+		env      string
+		xdg      string
+		expected string
+	}{
+		{
+			name:     "flag",
+			path:     "~/flag.yaml",
+			env:      "/env/config.yaml",
+			xdg:      "/xdg",
+			expected: filepath.Join(home, "flag.yaml"),
+		},
+		{
+			name:     "env",
+			env:      "~/env.yaml",
+			xdg:      "/xdg",
+			expected: filepath.Join(home, "env.yaml"),
+		},
+		{
+			name:     "xdg",
+			xdg:      "/xdg",
+			expected: filepath.Join("/xdg", "jt", "config.yaml"),
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ConfigEnvVar, tt.env)
+			t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+
+			got, err := ConfigPath(tt.path)
+			if err != nil {
+				t.Fatalf("failed to get config path: %s", err)
+			}
+			if got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	got, err := ConfigDir()
+	if err != nil {
+		t.Fatalf("failed to get config dir: %s", err)
+	}
+	if expected := filepath.Join("/xdg", "jt"); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	got, err = ConfigDir()
+	if err != nil {
+		t.Fatalf("failed to get config dir: %s", err)
+	}
+	if expected := filepath.Join(home, ".config", "jt"); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	testData := []struct {
+		path     string
+		expected string
+	}{
+		{path: "~", expected: home},
+		{path: "~/", expected: home},
+		{path: "~/.config/jt", expected: filepath.Join(home, ".config", "jt")},
+		{path: "/etc/jt", expected: "/etc/jt"},
+		{path: "relative/path", expected: "relative/path"},
+		{path: "/something/~/something", expected: "/something/~/something"},
+		{path: "~user/config", expected: "~user/config"},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := expandPath(tt.path)
+			if err != nil {
+				t.Fatalf("failed to expand path: %s", err)
+			}
+			if got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
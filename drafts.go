@@ -0,0 +1,156 @@
+package jt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	draftFileExt = ".jt"
+	// draftIDFormat is the time format used for draft IDs, which sorts by age.
+	draftIDFormat = "20060102-150405"
+)
+
+// Draft is an editor buffer that hasn't been turned into an issue yet.
+type Draft struct {
+	// ID identifies the draft, used to resume it.
+	ID string
+	// Path is the location of the draft file.
+	Path string
+	// Summary is the summary line of the draft, if it could be parsed.
+	Summary string
+	// Saved is when the draft was last saved.
+	Saved time.Time
+}
+
+// DraftsDir returns the directory unsent drafts are saved in.
+// $XDG_STATE_HOME/jt/drafts is used if set, otherwise %LocalAppData%\jt\drafts
+// on Windows and ~/.local/state/jt/drafts everywhere else.
+func DraftsDir() (string, error) {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+		return filepath.Join(xdg, configDirName, "drafts"), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine drafts directory: %w", err)
+		}
+		return filepath.Join(dir, configDirName, "drafts"), nil
+	}
+
+	return expandPath("~/.local/state/jt/drafts")
+}
+
+// SaveDraft saves the editor buffer as a draft. If id is empty a new draft is
+// created, otherwise the draft with that ID is overwritten.
+func SaveDraft(id string, buf string) (Draft, error) {
+	dir, err := DraftsDir()
+	if err != nil {
+		return Draft{}, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Draft{}, fmt.Errorf("failed to create drafts directory: %w", err)
+	}
+
+	if id == "" {
+		id = time.Now().Format(draftIDFormat)
+		// Don't overwrite drafts saved within the same second
+		for i := 2; exists(filepath.Join(dir, id+draftFileExt)); i++ {
+			id = fmt.Sprintf("%s-%d", time.Now().Format(draftIDFormat), i)
+		}
+	}
+
+	d := Draft{
+		ID:    id,
+		Path:  filepath.Join(dir, id+draftFileExt),
+		Saved: time.Now(),
+	}
+	if err := os.WriteFile(d.Path, []byte(buf), 0o600); err != nil {
+		return d, fmt.Errorf("failed to save draft: %w", err)
+	}
+	return d, nil
+}
+
+// LoadDraft returns the editor buffer of the draft.
+func LoadDraft(id string) (string, error) {
+	dir, err := DraftsDir()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, filepath.Base(id)+draftFileExt))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("draft %q not found", id)
+		}
+		return "", fmt.Errorf("failed to read draft: %w", err)
+	}
+	return string(b), nil
+}
+
+// RemoveDraft removes the draft.
+func RemoveDraft(id string) error {
+	dir, err := DraftsDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(dir, filepath.Base(id)+draftFileExt)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("draft %q not found", id)
+		}
+		return fmt.Errorf("failed to remove draft: %w", err)
+	}
+	return nil
+}
+
+// ListDrafts returns the saved drafts, oldest first.
+func ListDrafts(opts EditorOptions) ([]Draft, error) {
+	dir, err := DraftsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read drafts directory: %w", err)
+	}
+
+	var drafts []Draft
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != draftFileExt {
+			continue
+		}
+
+		d := Draft{
+			ID:   strings.TrimSuffix(e.Name(), draftFileExt),
+			Path: filepath.Join(dir, e.Name()),
+		}
+		if info, err := e.Info(); err == nil {
+			d.Saved = info.ModTime()
+		}
+		if b, err := os.ReadFile(d.Path); err == nil {
+			// Drafts can be saved with invalid headers, so only the summary matters here.
+			if ic, err := ParseBuffer(string(b), IssueConfig{}, opts); err == nil {
+				d.Summary = ic.Summary
+			}
+		}
+		drafts = append(drafts, d)
+	}
+
+	slices.SortFunc(drafts, func(a, b Draft) int {
+		return a.Saved.Compare(b.Saved)
+	})
+	return drafts, nil
+}
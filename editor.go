@@ -1,87 +1,502 @@
 package jt
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 const (
+	// DefaultEditor is the first editor tried if none is configured.
 	DefaultEditor = "vim"
 
-	boilerPlate = `%s%s
-# Please enter the issue summary on the first line.
-# Separate the summary from the description with an empty line.
-# Lines starting with '#' will be ignored.
-# The rest of the file will be used as the issue description.
-`
+	// EditorEnvVar overrides all other editor settings.
+	EditorEnvVar = "JT_EDITOR"
+
+	// DefaultCommentChar starts comment lines in the editor buffer.
+	DefaultCommentChar = "#"
+
+	// headerDelimiter opens and closes the header block in the editor buffer.
+	headerDelimiter = "---"
+
+	// scissors marks the start of the help text in the editor buffer.
+	// It's prefixed with the comment char, everything after it is ignored.
+	scissors = " ------------------------ >8 ------------------------"
+)
+
+// Cleanup modes for the editor buffer, mirroring git commit --cleanup.
+const (
+	// CleanupScissors only removes comment lines before the summary and
+	// everything after the scissors line. This is the default.
+	CleanupScissors = "scissors"
+	// CleanupStrip removes all comment lines outside fenced code blocks, as
+	// well as everything after the scissors line.
+	CleanupStrip = "strip"
+)
+
+var helpText = []string{
+	"Do not modify or remove the line above.",
+	"Everything below it will be ignored.",
+	"",
+	"Please enter the issue summary on the first line after the header.",
+	"Separate the summary from the description with an empty line.",
+	"The rest of the file will be used as the issue description.",
+	"",
+	"The optional header block between the '---' lines sets the issue fields.",
+	`Labels and Components are comma separated, Assignee can be "me".`,
+}
+
+// Header keys available in the header block of the editor buffer.
+const (
+	HeaderType       = "Type"
+	HeaderParent     = "Parent"
+	HeaderAssignee   = "Assignee"
+	HeaderLabels     = "Labels"
+	HeaderComponents = "Components"
 )
 
 var (
 	ErrEmptySummary = fmt.Errorf("aborting, summary empty")
+	// ErrNoTerminal is returned instead of opening the editor when stdin isn't
+	// a terminal, such as in a pipeline, where nobody could use the editor.
+	ErrNoTerminal = errors.New("can't open the editor without a terminal")
+
+	// stdinIsTerminal reports whether stdin is a terminal, replaced in tests.
+	stdinIsTerminal = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+
+	// fallbackEditors are tried in order if no editor is configured.
+	fallbackEditors = []string{DefaultEditor, "nano", "vi"}
 )
 
-// OpenInEditor opens the user's default editor and returns the contents of the
-// file.
-func OpenInEditor(s string, d string) (string, string, error) {
+// EditorOptions configures the editor buffer.
+type EditorOptions struct {
+	// IssueTypes are the issue types available in the project, listed in the help text.
+	IssueTypes []string
+	// Components are the components available in the project, listed in the help text.
+	Components []string
+	// CommentChar starts comment lines. Defaults to DefaultCommentChar.
+	CommentChar string
+	// Cleanup is the cleanup mode, CleanupScissors or CleanupStrip.
+	// Defaults to CleanupScissors.
+	Cleanup string
+	// Command is the editor command from the config, including any arguments.
+	// See EditorCommand for how the editor is chosen.
+	Command string
+}
+
+func (o EditorOptions) commentChar() string {
+	if o.CommentChar == "" {
+		return DefaultCommentChar
+	}
+	return o.CommentChar
+}
+
+// OpenInEditor opens the user's default editor with the issue config rendered
+// into the buffer and returns the issue config with the edited fields.
+func OpenInEditor(ic IssueConfig, opts EditorOptions) (IssueConfig, error) {
+	buf, err := EditBuffer(RenderBuffer(ic, opts), opts)
+	if err != nil {
+		return ic, err
+	}
+	return ParseBuffer(buf, ic, opts)
+}
+
+// CheckTerminal returns ErrNoTerminal if the editor can't be opened because
+// stdin isn't a terminal.
+func CheckTerminal() error {
+	if !stdinIsTerminal() {
+		return ErrNoTerminal
+	}
+	return nil
+}
+
+// EditBuffer opens the user's default editor with the provided buffer and
+// returns the edited buffer. It returns ErrNoTerminal without opening the
+// editor if stdin isn't a terminal.
+func EditBuffer(buf string, opts EditorOptions) (string, error) {
+	if err := CheckTerminal(); err != nil {
+		return "", err
+	}
+	switch opts.Cleanup {
+	case "", CleanupScissors, CleanupStrip:
+	default:
+		return "", fmt.Errorf("unknown cleanup mode %q, expected %q or %q", opts.Cleanup, CleanupScissors, CleanupStrip)
+	}
+
 	// Create a temporary file
 	tmpfile, err := os.CreateTemp("", "*-ISSUE_MSG.jt")
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create temporary file: %s", err)
+		return "", fmt.Errorf("failed to create temporary file: %s", err)
 	}
 	defer os.Remove(tmpfile.Name())
 
-	// Write the template to the file
-	if d != "" {
-		d = "\n\n" + d
-	}
-	_, err = tmpfile.WriteString(fmt.Sprintf(boilerPlate, s, d))
+	// Write the buffer to the file
+	_, err = tmpfile.WriteString(buf)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to write boilerplate to file: %s", err)
+		return "", fmt.Errorf("failed to write boilerplate to file: %s", err)
 	}
-	// Open the file in EDITOR
-	e := os.Getenv("EDITOR")
-	if e == "" {
-		e = DefaultEditor
+	// Close the file so editors that replace it on save work on all platforms
+	if err := tmpfile.Close(); err != nil {
+		return "", fmt.Errorf("failed to write boilerplate to file: %s", err)
 	}
-	cmd := exec.Command(e, tmpfile.Name())
+
+	// Open the file in the editor
+	e, err := EditorCommand(opts)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(e[0], append(e[1:], tmpfile.Name())...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err = cmd.Run()
 	if err != nil {
-		return "", "", err
+		return "", fmt.Errorf("editor %q failed: %w", strings.Join(e, " "), err)
 	}
 
 	// Read the resulting file
 	result, err := os.ReadFile(tmpfile.Name())
 	if err != nil {
-		return "", "", err
+		return "", err
 	}
+	return string(result), nil
+}
 
-	// Parse the file into summary and description
-	lines := strings.Split(string(result), "\n")
-	var summary, description string
-	for _, line := range lines {
-		// Skip empty lines and comments
-		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+// EditorCommand returns the editor command and its arguments. The first of
+// the following that is set is used:
+//   - $JT_EDITOR
+//   - the editor from the config
+//   - $VISUAL
+//   - $EDITOR
+//   - git's core.editor
+//   - the first of vim, nano and vi found in $PATH
+//
+// The command is split into arguments like a shell would, so values such as
+// "code --wait" work.
+func EditorCommand(opts EditorOptions) ([]string, error) {
+	// git is only run if none of the others are set
+	candidates := []func() string{
+		func() string { return os.Getenv(EditorEnvVar) },
+		func() string { return opts.Command },
+		func() string { return os.Getenv("VISUAL") },
+		func() string { return os.Getenv("EDITOR") },
+		gitEditor,
+	}
+	for _, candidate := range candidates {
+		e := candidate()
+		if strings.TrimSpace(e) == "" {
 			continue
 		}
-		// Assign the first valid line to summary
-		if summary == "" {
-			summary = line
+		args, err := splitShellWords(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid editor %q: %w", e, err)
+		}
+		return args, nil
+	}
+
+	for _, e := range fallbackEditors {
+		if _, err := exec.LookPath(e); err == nil {
+			return []string{e}, nil
+		}
+	}
+	return nil, fmt.Errorf("no editor found, set $%s, $VISUAL or $EDITOR", EditorEnvVar)
+}
+
+// gitEditor returns git's core.editor, or an empty string if it's not set or
+// git isn't installed. It's replaced in tests.
+var gitEditor = func() string {
+	out, err := exec.Command("git", "config", "core.editor").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// splitShellWords splits s into words like a POSIX shell, without expansions.
+// Words can be quoted with single or double quotes. Outside single quotes, a
+// backslash escapes quotes, whitespace and backslashes, and is otherwise kept
+// as is so Windows paths don't need escaping.
+func splitShellWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\\\"' \t", runes[i+1]) &&
+			(quote == 0 || runes[i+1] == '"' || runes[i+1] == '\\'):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return words, nil
+}
+
+// AnnotateBuffer returns the buffer with the error added as comment lines at
+// the top, replacing any previous annotations. Comment lines at the top of the
+// buffer are ignored when parsing, so the buffer can be edited and parsed again.
+func AnnotateBuffer(buf string, err error, opts EditorOptions) string {
+	cc := opts.commentChar()
+
+	// Remove the previous annotations
+	lines := strings.Split(buf, "\n")
+	for len(lines) > 0 && (strings.HasPrefix(lines[0], cc) || strings.TrimSpace(lines[0]) == "") {
+		lines = lines[1:]
+	}
+
+	var msgs []string
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		msgs = append([]string{fmt.Sprintf("JIRA rejected the issue with status %d:", apiErr.StatusCode)}, apiErr.Messages...)
+	} else {
+		msgs = strings.Split(strings.TrimSpace(err.Error()), "\n")
+	}
+
+	var annotated strings.Builder
+	for i, msg := range msgs {
+		if i > 0 && apiErr != nil {
+			msg = "  " + msg
+		}
+		annotated.WriteString(cc + " jt: " + msg + "\n")
+	}
+	annotated.WriteString(cc + " Fix the issue and save to try again, or empty the summary to abort.\n")
+	annotated.WriteString("\n")
+	annotated.WriteString(strings.Join(lines, "\n"))
+	return annotated.String()
+}
+
+// RenderBuffer renders the issue config into the editor buffer.
+func RenderBuffer(ic IssueConfig, opts EditorOptions) string {
+	var buf strings.Builder
+	renderIssue(&buf, ic)
+	renderHelp(&buf, helpText, opts)
+	return buf.String()
+}
+
+// renderIssue renders the header block, summary and description of the issue.
+func renderIssue(buf *strings.Builder, ic IssueConfig) {
+	buf.WriteString(headerDelimiter + "\n")
+	for _, h := range [][2]string{
+		{HeaderType, ic.IssueType},
+		{HeaderParent, ic.ParentIssueKey},
+		{HeaderAssignee, ic.Assignee},
+		{HeaderLabels, strings.Join(ic.Labels, ", ")},
+		{HeaderComponents, strings.Join(ic.ComponentNames, ", ")},
+	} {
+		fmt.Fprintf(buf, "%s: %s\n", h[0], h[1])
+	}
+	buf.WriteString(headerDelimiter + "\n")
+
+	buf.WriteString(ic.Summary + "\n")
+	if ic.Description != "" {
+		buf.WriteString("\n" + strings.TrimRight(ic.Description, "\n") + "\n")
+	}
+}
+
+// renderHelp renders the scissors line followed by the help text, with the
+// values available in the project.
+func renderHelp(buf *strings.Builder, help []string, opts EditorOptions) {
+	cc := opts.commentChar()
+	buf.WriteString(cc + scissors + "\n")
+	help = slices.Clone(help)
+	if len(opts.IssueTypes) > 0 {
+		help = append(help, "", "Available types: "+strings.Join(opts.IssueTypes, ", "))
+	}
+	if len(opts.Components) > 0 {
+		help = append(help, "Available components: "+strings.Join(opts.Components, ", "))
+	}
+	for _, line := range help {
+		if line == "" {
+			buf.WriteString(cc + "\n")
 			continue
 		}
+		buf.WriteString(cc + " " + line + "\n")
+	}
+}
+
+// ParseBuffer parses the editor buffer into a copy of the provided issue config.
+//
+// Like git commit messages, comment lines before the summary and everything
+// after the scissors line are removed. With CleanupStrip, all other comment
+// lines are removed too, except in fenced code blocks. Blank lines in the
+// description are kept as paragraph breaks.
+func ParseBuffer(buf string, ic IssueConfig, opts EditorOptions) (IssueConfig, error) {
+	cc := opts.commentChar()
+	isComment := func(line string) bool {
+		return strings.HasPrefix(line, cc)
+	}
+	isBlank := func(line string) bool {
+		return strings.TrimSpace(line) == ""
+	}
+
+	lines := cutScissors(strings.Split(buf, "\n"), cc)
+
+	// Skip empty lines and comments before the header or summary
+	for len(lines) > 0 && (isComment(lines[0]) || isBlank(lines[0])) {
+		lines = lines[1:]
+	}
 
-		// Assign the rest to description
-		description += line + "\n"
+	// Parse the optional header block
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == headerDelimiter {
+		n, err := parseHeader(lines[1:], &ic, isComment)
+		if err != nil {
+			return ic, err
+		}
+		lines = lines[1+n:]
+
+		for len(lines) > 0 && (isComment(lines[0]) || isBlank(lines[0])) {
+			lines = lines[1:]
+		}
 	}
 
 	// Check if we have a summary
-	if summary == "" {
-		return "", "", ErrEmptySummary
+	if len(lines) == 0 {
+		return ic, ErrEmptySummary
+	}
+	ic.Summary = strings.TrimSpace(lines[0])
+
+	// The rest is the description
+	var description []string
+	inFence := false
+	for _, line := range lines[1:] {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if inFence {
+			description = append(description, line)
+			continue
+		}
+		if opts.Cleanup == CleanupStrip && isComment(line) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		// Collapse consecutive blank lines
+		if line == "" && len(description) > 0 && description[len(description)-1] == "" {
+			continue
+		}
+		description = append(description, line)
+	}
+
+	// Trim leading and trailing blank lines
+	for len(description) > 0 && isBlank(description[0]) {
+		description = description[1:]
+	}
+	for len(description) > 0 && isBlank(description[len(description)-1]) {
+		description = description[:len(description)-1]
 	}
 
-	return summary, description, nil
+	ic.Description = ""
+	if len(description) > 0 {
+		ic.Description = strings.Join(description, "\n") + "\n"
+	}
+	return ic, nil
+}
+
+// cutScissors drops the last scissors line and everything after it. jt writes
+// the scissors line itself, so it's cut wherever it is, even after an unclosed
+// code block. Earlier scissors lines, like one pasted into the description,
+// are kept.
+func cutScissors(lines []string, commentChar string) []string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] == commentChar+scissors {
+			return lines[:i]
+		}
+	}
+	return lines
+}
+
+// parseHeader parses "Key: value" lines into the issue config until the closing
+// delimiter. It returns the number of lines consumed, including the delimiter.
+func parseHeader(lines []string, ic *IssueConfig, isComment func(string) bool) (int, error) {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == headerDelimiter {
+			return i + 1, nil
+		}
+		if isComment(line) || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid header line %q, expected \"Key: value\"", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch k := strings.TrimSpace(key); {
+		case strings.EqualFold(k, HeaderType):
+			ic.IssueType = value
+		case strings.EqualFold(k, HeaderParent):
+			ic.ParentIssueKey = value
+		case strings.EqualFold(k, HeaderAssignee):
+			ic.Assignee = value
+		case strings.EqualFold(k, HeaderLabels):
+			ic.Labels = splitList(value)
+		case strings.EqualFold(k, HeaderComponents):
+			ic.ComponentNames = splitList(value)
+		default:
+			return 0, fmt.Errorf("unknown header %q, expected one of %s", k,
+				strings.Join([]string{HeaderType, HeaderParent, HeaderAssignee, HeaderLabels, HeaderComponents}, ", "))
+		}
+	}
+	return 0, fmt.Errorf("header block not closed with %q", headerDelimiter)
+}
+
+// isFence reports whether the line opens or closes a fenced code block.
+func isFence(line string) bool {
+	l := strings.TrimSpace(line)
+	return strings.HasPrefix(l, "```") || strings.HasPrefix(l, "~~~")
+}
+
+// splitList splits a comma separated list, dropping empty values.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
 }
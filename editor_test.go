@@ -0,0 +1,372 @@
+package jt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseBufferHeader(t *testing.T) {
+	ic := IssueConfig{
+		ProjectKey:     "PRJ",
+		IssueType:      "Task",
+		ComponentNames: []string{"Team A"},
+	}
+
+	buf := RenderBuffer(ic, EditorOptions{IssueTypes: []string{"Bug", "Task"}})
+	// Simulate the user filling in the buffer
+	buf = strings.Replace(buf, "Type: Task", "Type: Bug", 1)
+	buf = strings.Replace(buf, "Parent: ", "parent: PRJ-12", 1)
+	buf = strings.Replace(buf, "Assignee: ", "Assignee: me", 1)
+	buf = strings.Replace(buf, "Labels: ", "Labels: a, b,", 1)
+	buf = strings.Replace(buf, "Components: Team A", "Components:", 1)
+	buf = strings.Replace(buf, "---\n\n", "---\nMy summary\n\nMy description\n", 1)
+
+	got, err := ParseBuffer(buf, ic, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+
+	if got.Summary != "My summary" {
+		t.Errorf("expected summary %q, got %q", "My summary", got.Summary)
+	}
+	if got.Description != "My description\n" {
+		t.Errorf("expected description %q, got %q", "My description\n", got.Description)
+	}
+	if got.IssueType != "Bug" {
+		t.Errorf("expected type %q, got %q", "Bug", got.IssueType)
+	}
+	if got.ParentIssueKey != "PRJ-12" {
+		t.Errorf("expected parent %q, got %q", "PRJ-12", got.ParentIssueKey)
+	}
+	if got.Assignee != "me" {
+		t.Errorf("expected assignee %q, got %q", "me", got.Assignee)
+	}
+	if !slices.Equal(got.Labels, []string{"a", "b"}) {
+		t.Errorf("expected labels %q, got %q", []string{"a", "b"}, got.Labels)
+	}
+	if got.ComponentNames != nil {
+		t.Errorf("expected no components, got %q", got.ComponentNames)
+	}
+	if got.ProjectKey != "PRJ" {
+		t.Errorf("expected project %q to be kept, got %q", "PRJ", got.ProjectKey)
+	}
+}
+
+func TestParseBufferWithoutHeader(t *testing.T) {
+	ic := IssueConfig{IssueType: "Task"}
+
+	got, err := ParseBuffer("My summary\n\nMy description\n", ic, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+	if got.Summary != "My summary" || got.Description != "My description\n" || got.IssueType != "Task" {
+		t.Fatalf("unexpected issue config %+v", got)
+	}
+}
+
+func TestParseBufferInvalidHeader(t *testing.T) {
+	testData := []struct {
+		name   string
+		buf    string
+		errMsg string
+	}{
+		{
+			name:   "unknown header",
+			buf:    "---\nPriority: High\n---\nSummary\n",
+			errMsg: "unknown header",
+		},
+		{
+			name:   "missing colon",
+			buf:    "---\nType Bug\n---\nSummary\n",
+			errMsg: "invalid header line",
+		},
+		{
+			name:   "unclosed header",
+			buf:    "---\nType: Bug\nSummary\n",
+			errMsg: "invalid header line",
+		},
+		{
+			name:   "empty summary",
+			buf:    "---\nType: Bug\n---\n",
+			errMsg: ErrEmptySummary.Error(),
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBuffer(tt.buf, IssueConfig{}, EditorOptions{})
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseBufferKeepsFormatting(t *testing.T) {
+	desc := `First paragraph
+still the first paragraph.
+
+# Steps
+
+` + "```sh" + `
+# install dependencies
+make deps
+
+
+make test
+` + "```" + `
+
+Last paragraph
+`
+
+	buf := RenderBuffer(IssueConfig{Summary: "My summary", Description: desc}, EditorOptions{})
+	got, err := ParseBuffer(buf, IssueConfig{}, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+	if got.Summary != "My summary" {
+		t.Errorf("expected summary %q, got %q", "My summary", got.Summary)
+	}
+	if got.Description != desc {
+		t.Errorf("expected description %q, got %q", desc, got.Description)
+	}
+}
+
+func TestParseBufferCleanup(t *testing.T) {
+	// The comment char is substituted for {c}
+	buf := `{c} leading comment
+
+Summary
+# Heading
+
+text   
+{c} comment
+` + "```" + `
+{c} code comment
+` + "```" + `
+
+
+{c} ------------------------ >8 ------------------------
+{c} help text
+`
+
+	testData := []struct {
+		name     string
+		opts     EditorOptions
+		expected string
+	}{
+		{
+			name:     "scissors",
+			opts:     EditorOptions{},
+			expected: "# Heading\n\ntext\n# comment\n```\n# code comment\n```\n",
+		},
+		{
+			name:     "strip",
+			opts:     EditorOptions{Cleanup: CleanupStrip},
+			expected: "text\n```\n# code comment\n```\n",
+		},
+		{
+			name:     "strip with comment char",
+			opts:     EditorOptions{CommentChar: ";", Cleanup: CleanupStrip},
+			expected: "# Heading\n\ntext\n```\n; code comment\n```\n",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBuffer(strings.ReplaceAll(buf, "{c}", tt.opts.commentChar()), IssueConfig{}, tt.opts)
+			if err != nil {
+				t.Fatalf("failed to parse buffer: %s", err)
+			}
+			if got.Summary != "Summary" {
+				t.Errorf("expected summary %q, got %q", "Summary", got.Summary)
+			}
+			if got.Description != tt.expected {
+				t.Errorf("expected description %q, got %q", tt.expected, got.Description)
+			}
+		})
+	}
+}
+
+func TestParseBufferScissorsAfterFence(t *testing.T) {
+	help := "# ------------------------ >8 ------------------------\n# help text\n"
+
+	testData := []struct {
+		name     string
+		buf      string
+		expected string
+	}{
+		{
+			name:     "unclosed fence",
+			buf:      "Summary\n\n```\ncode\n" + help,
+			expected: "```\ncode\n",
+		},
+		{
+			name:     "single line fence",
+			buf:      "Summary\n\n```foo```\ntext\n" + help,
+			expected: "```foo```\ntext\n",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBuffer(tt.buf, IssueConfig{}, EditorOptions{})
+			if err != nil {
+				t.Fatalf("failed to parse buffer: %s", err)
+			}
+			if got.Description != tt.expected {
+				t.Fatalf("expected description %q, got %q", tt.expected, got.Description)
+			}
+		})
+	}
+}
+
+func TestAnnotateBuffer(t *testing.T) {
+	ic := IssueConfig{Summary: "My summary", Description: "My description\n", IssueType: "Task"}
+	buf := RenderBuffer(ic, EditorOptions{})
+
+	apiErr := &APIError{StatusCode: 400, Messages: []string{"components: Component name 'X' is not valid"}}
+	annotated := AnnotateBuffer(buf, apiErr, EditorOptions{})
+	if !strings.HasPrefix(annotated, "# jt: JIRA rejected the issue with status 400:\n# jt:   components: Component name 'X' is not valid\n") {
+		t.Fatalf("expected buffer to start with the error, got %q", annotated)
+	}
+
+	// Annotating again replaces the previous annotations
+	annotated = AnnotateBuffer(annotated, errors.New("failed to find assignee"), EditorOptions{})
+	if strings.Contains(annotated, "Component name") || !strings.HasPrefix(annotated, "# jt: failed to find assignee\n") {
+		t.Fatalf("expected previous annotations to be replaced, got %q", annotated)
+	}
+
+	got, err := ParseBuffer(annotated, IssueConfig{}, EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to parse buffer: %s", err)
+	}
+	if got.Summary != ic.Summary || got.Description != ic.Description || got.IssueType != ic.IssueType {
+		t.Fatalf("expected annotations to be ignored, got %+v", got)
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	testData := []struct {
+		in       string
+		expected []string
+	}{
+		{in: "vim", expected: []string{"vim"}},
+		{in: "  code   --wait ", expected: []string{"code", "--wait"}},
+		{in: "emacsclient -t -a ''", expected: []string{"emacsclient", "-t", "-a", ""}},
+		{in: `"/Applications/Sublime Text.app/bin/subl" -w`, expected: []string{"/Applications/Sublime Text.app/bin/subl", "-w"}},
+		{in: `/opt/my\ editor/bin/ed --flag='a "b"'`, expected: []string{"/opt/my editor/bin/ed", `--flag=a "b"`}},
+		{in: `"say \"hi\" \n"`, expected: []string{`say "hi" \n`}},
+		{in: `C:\Windows\notepad.exe`, expected: []string{`C:\Windows\notepad.exe`}},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := splitShellWords(tt.in)
+			if err != nil {
+				t.Fatalf("failed to split %q: %s", tt.in, err)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	for _, in := range []string{"", "  ", `vim "unterminated`, "vim 'unterminated"} {
+		if _, err := splitShellWords(in); err == nil {
+			t.Errorf("expected error splitting %q, got nil", in)
+		}
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv(EditorEnvVar, "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "emacsclient -t")
+
+	got, err := EditorCommand(EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to get editor command: %s", err)
+	}
+	if !slices.Equal(got, []string{"emacsclient", "-t"}) {
+		t.Fatalf("expected $EDITOR to be used, got %q", got)
+	}
+
+	t.Setenv("VISUAL", "code --wait")
+	got, _ = EditorCommand(EditorOptions{})
+	if !slices.Equal(got, []string{"code", "--wait"}) {
+		t.Fatalf("expected $VISUAL to take precedence over $EDITOR, got %q", got)
+	}
+
+	got, _ = EditorCommand(EditorOptions{Command: "nano"})
+	if !slices.Equal(got, []string{"nano"}) {
+		t.Fatalf("expected the config to take precedence over $VISUAL, got %q", got)
+	}
+
+	t.Setenv(EditorEnvVar, "vi")
+	got, _ = EditorCommand(EditorOptions{Command: "nano"})
+	if !slices.Equal(got, []string{"vi"}) {
+		t.Fatalf("expected $%s to take precedence over the config, got %q", EditorEnvVar, got)
+	}
+}
+
+func TestEditorCommandGitEditor(t *testing.T) {
+	git := gitEditor
+	defer func() { gitEditor = git }()
+	calls := 0
+	gitEditor = func() string {
+		calls++
+		return "hx"
+	}
+
+	t.Setenv(EditorEnvVar, "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "emacs")
+	if _, err := EditorCommand(EditorOptions{}); err != nil {
+		t.Fatalf("failed to get editor command: %s", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected git not to be run when $EDITOR is set, got %d calls", calls)
+	}
+
+	t.Setenv("EDITOR", "")
+	got, err := EditorCommand(EditorOptions{})
+	if err != nil {
+		t.Fatalf("failed to get editor command: %s", err)
+	}
+	if !slices.Equal(got, []string{"hx"}) {
+		t.Fatalf("expected git's core.editor to be used, got %q", got)
+	}
+}
+
+func TestEditBufferWithoutTerminal(t *testing.T) {
+	isTerminal := stdinIsTerminal
+	defer func() { stdinIsTerminal = isTerminal }()
+
+	// The editor would create the marker file if it were opened
+	marker := filepath.Join(t.TempDir(), "opened")
+	t.Setenv(EditorEnvVar, "touch "+marker)
+
+	stdinIsTerminal = func() bool { return false }
+	if _, err := EditBuffer("summary", EditorOptions{}); !errors.Is(err, ErrNoTerminal) {
+		t.Fatalf("expected ErrNoTerminal, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatalf("expected the editor not to be opened")
+	}
+
+	stdinIsTerminal = func() bool { return true }
+	if _, err := EditBuffer("summary", EditorOptions{}); err != nil {
+		t.Fatalf("failed to edit buffer: %s", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("expected the editor to be opened, got %s", err)
+	}
+}
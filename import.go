@@ -0,0 +1,297 @@
+package jt
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Import columns, or keys in YAML and JSON files. Columns starting with
+// CustomFieldPrefix are set as custom fields.
+const (
+	ImportColumnID          = "id"
+	ImportColumnSummary     = "summary"
+	ImportColumnDescription = "description"
+	ImportColumnType        = "type"
+	ImportColumnProject     = "project"
+	ImportColumnParent      = "parent"
+	ImportColumnAssignee    = "assignee"
+	ImportColumnLabels      = "labels"
+	ImportColumnComponents  = "components"
+)
+
+var importColumns = []string{
+	ImportColumnID,
+	ImportColumnSummary,
+	ImportColumnDescription,
+	ImportColumnType,
+	ImportColumnProject,
+	ImportColumnParent,
+	ImportColumnAssignee,
+	ImportColumnLabels,
+	ImportColumnComponents,
+}
+
+// ImportRow is an issue read from an import file.
+type ImportRow struct {
+	IssueConfig
+	// Row is the position of the row in the file, starting at 1 and not
+	// counting the CSV header.
+	Row int
+	// ID identifies the row so other rows can refer to it as their parent.
+	ID string
+	// ParentID is the ID of the parent row in the same file, if any.
+	// ParentIssueKey is set instead if the parent isn't in the file.
+	ParentID string
+}
+
+// ReadImportFile reads issues from a CSV, YAML or JSON file, depending on the
+// file extension. CSV files must have a header row with the column names.
+// YAML and JSON files must contain a list of objects keyed by column name.
+//
+// Labels and components are comma separated lists, or lists in YAML and JSON.
+// The project, type and components default to the ones in the issue config.
+func ReadImportFile(path string, defaults IssueConfig) ([]ImportRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+
+	var records []map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		records, err = readCSVRecords(f)
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(f).Decode(&records)
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	default:
+		return nil, fmt.Errorf("unsupported import file extension %q, expected .csv, .yaml, .yml or .json", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode import file: %w", err)
+	}
+
+	rows := make([]ImportRow, 0, len(records))
+	ids := make(map[string]bool)
+	for i, record := range records {
+		row, err := newImportRow(record, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		row.Row = i + 1
+		if row.ID != "" {
+			if ids[row.ID] {
+				return nil, fmt.Errorf("row %d: duplicate id %q", row.Row, row.ID)
+			}
+			ids[row.ID] = true
+		}
+		rows = append(rows, row)
+	}
+
+	// Parents that refer to rows in the file are created first,
+	// anything else is an existing issue key.
+	for i, row := range rows {
+		if ids[row.ParentIssueKey] {
+			if row.ParentIssueKey == row.ID {
+				return nil, fmt.Errorf("row %d: issue can't be its own parent", row.Row)
+			}
+			rows[i].ParentID = row.ParentIssueKey
+			rows[i].ParentIssueKey = ""
+		}
+	}
+
+	return rows, nil
+}
+
+// ImportOrder groups the rows so that each row comes after its parent.
+// Rows in the same group don't depend on each other and can be created together.
+func ImportOrder(rows []ImportRow) ([][]ImportRow, error) {
+	var groups [][]ImportRow
+	done := make(map[string]bool)
+	remaining := rows
+
+	for len(remaining) > 0 {
+		var group, next []ImportRow
+		for _, row := range remaining {
+			if row.ParentID == "" || done[row.ParentID] {
+				group = append(group, row)
+			} else {
+				next = append(next, row)
+			}
+		}
+		if len(group) == 0 {
+			return nil, fmt.Errorf("row %d: parent %q is part of a cycle", next[0].Row, next[0].ParentID)
+		}
+		for _, row := range group {
+			done[row.ID] = true
+		}
+		groups = append(groups, group)
+		remaining = next
+	}
+	return groups, nil
+}
+
+func newImportRow(record map[string]any, defaults IssueConfig) (ImportRow, error) {
+	row := ImportRow{IssueConfig: IssueConfig{
+		ProjectKey:     defaults.ProjectKey,
+		IssueType:      defaults.IssueType,
+		ComponentNames: defaults.ComponentNames,
+	}}
+
+	for key, value := range record {
+		column := strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(column, CustomFieldPrefix) {
+			if value == nil || value == "" {
+				continue
+			}
+			if row.CustomFields == nil {
+				row.CustomFields = make(map[string]any)
+			}
+			row.CustomFields[column] = value
+			continue
+		}
+
+		if !slices.Contains(importColumns, column) {
+			return row, fmt.Errorf("unknown column %q, expected one of %s or %sXXXXX",
+				key, strings.Join(importColumns, ", "), CustomFieldPrefix)
+		}
+
+		if column == ImportColumnLabels || column == ImportColumnComponents {
+			list, err := importList(value)
+			if err != nil {
+				return row, fmt.Errorf("column %q: %w", key, err)
+			}
+			if column == ImportColumnLabels {
+				row.Labels = list
+			} else if list != nil {
+				row.ComponentNames = list
+			}
+			continue
+		}
+
+		s, err := importString(value)
+		if err != nil {
+			return row, fmt.Errorf("column %q: %w", key, err)
+		}
+		switch column {
+		case ImportColumnID:
+			row.ID = s
+		case ImportColumnSummary:
+			row.Summary = s
+		case ImportColumnDescription:
+			row.Description = s
+		case ImportColumnType:
+			if s != "" {
+				row.IssueType = s
+			}
+		case ImportColumnProject:
+			if s != "" {
+				row.ProjectKey = s
+			}
+		case ImportColumnParent:
+			row.ParentIssueKey = s
+		case ImportColumnAssignee:
+			row.Assignee = s
+		}
+	}
+
+	if row.Summary == "" {
+		return row, fmt.Errorf("summary is required")
+	}
+	return row, nil
+}
+
+// readCSVRecords reads the CSV rows into records keyed by the header row.
+func readCSVRecords(r io.Reader) ([]map[string]any, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	var records []map[string]any
+	for {
+		values, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := make(map[string]any, len(header))
+		for i, column := range header {
+			record[column] = values[i]
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(column)), CustomFieldPrefix) {
+				record[column] = csvValue(values[i])
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+// numberRe matches plain numbers, without leading zeros so values like "007"
+// stay strings.
+var numberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+// csvValue returns the CSV value of a custom field as a number if it's a plain
+// number, since CSV has no types and JIRA rejects strings for number fields.
+func csvValue(s string) any {
+	s = strings.TrimSpace(s)
+	if !numberRe.MatchString(s) {
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+func importString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(v), nil
+	case int, int64, float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("expected a string, got %T", v)
+	}
+}
+
+func importList(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return splitList(v), nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := importString(item)
+			if err != nil {
+				return nil, err
+			}
+			if s != "" {
+				list = append(list, s)
+			}
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("expected a list or a comma separated string, got %T", v)
+	}
+}
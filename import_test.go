@@ -0,0 +1,168 @@
+package jt
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadImportFile(t *testing.T) {
+	files := map[string]string{
+		"issues.csv": `id,Summary,type,parent,labels,components,customfield_10016
+epic,The epic,Epic,,"a, b",,
+,A story,Story,epic,,Team B,3
+,Another story,,PRJ-1,,,
+`,
+		"issues.yaml": `- id: epic
+  summary: The epic
+  type: Epic
+  labels: [a, b]
+- summary: A story
+  type: Story
+  parent: epic
+  components: Team B
+  customfield_10016: 3
+- summary: Another story
+  parent: PRJ-1
+`,
+		"issues.json": `[
+  {"id": "epic", "summary": "The epic", "type": "Epic", "labels": "a,b"},
+  {"summary": "A story", "type": "Story", "parent": "epic", "components": ["Team B"], "customfield_10016": 3},
+  {"summary": "Another story", "parent": "PRJ-1"}
+]`,
+	}
+
+	defaults := IssueConfig{ProjectKey: "PRJ", IssueType: "Task", ComponentNames: []string{"Team A"}}
+	dir := t.TempDir()
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := ReadImportFile(path, defaults)
+			if err != nil {
+				t.Fatalf("failed to read import file: %s", err)
+			}
+			if len(rows) != 3 {
+				t.Fatalf("expected 3 rows, got %d", len(rows))
+			}
+
+			epic, story, other := rows[0], rows[1], rows[2]
+			if epic.ID != "epic" || epic.IssueType != "Epic" || !slices.Equal(epic.Labels, []string{"a", "b"}) ||
+				!slices.Equal(epic.ComponentNames, []string{"Team A"}) || epic.ProjectKey != "PRJ" {
+				t.Errorf("unexpected epic row %+v", epic)
+			}
+			if story.ParentID != "epic" || story.ParentIssueKey != "" || !slices.Equal(story.ComponentNames, []string{"Team B"}) {
+				t.Errorf("unexpected story row %+v", story)
+			}
+			if got := customFieldsJSON(t, story); got != `{"customfield_10016":3}` {
+				t.Errorf("expected custom fields %s, got %s", `{"customfield_10016":3}`, got)
+			}
+			if other.ParentID != "" || other.ParentIssueKey != "PRJ-1" || other.IssueType != "Task" {
+				t.Errorf("unexpected row %+v", other)
+			}
+
+			groups, err := ImportOrder(rows)
+			if err != nil {
+				t.Fatalf("failed to order rows: %s", err)
+			}
+			if len(groups) != 2 || len(groups[0]) != 2 || groups[1][0].Summary != "A story" {
+				t.Errorf("expected the story to be created after the epic, got %+v", groups)
+			}
+		})
+	}
+}
+
+func TestReadImportFileCSVCustomFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issues.csv")
+	content := "summary,customfield_1,customfield_2,customfield_3,customfield_4,customfield_5\nA story, 3 ,-1.5,Team A,007,\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadImportFile(path, IssueConfig{})
+	if err != nil {
+		t.Fatalf("failed to read import file: %s", err)
+	}
+
+	// Plain numbers are sent as numbers, anything else as strings.
+	expected := `{"customfield_1":3,"customfield_2":-1.5,"customfield_3":"Team A","customfield_4":"007"}`
+	if got := customFieldsJSON(t, rows[0]); got != expected {
+		t.Fatalf("expected custom fields %s, got %s", expected, got)
+	}
+}
+
+// customFieldsJSON returns the custom fields of the create request for the
+// row, as they're sent to JIRA.
+func customFieldsJSON(t *testing.T, row ImportRow) string {
+	t.Helper()
+	req, err := NewJiraClient(JiraConfig{}).BuildCreateIssueRequest(row.IssueConfig)
+	if err != nil {
+		t.Fatalf("failed to build request: %s", err)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("failed to marshal request: %s", err)
+	}
+
+	var body struct {
+		Fields map[string]json.RawMessage `json:"fields"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		t.Fatalf("failed to unmarshal request: %s", err)
+	}
+	custom := make(map[string]json.RawMessage)
+	for k, v := range body.Fields {
+		if strings.HasPrefix(k, CustomFieldPrefix) {
+			custom[k] = v
+		}
+	}
+	b, err = json.Marshal(custom)
+	if err != nil {
+		t.Fatalf("failed to marshal custom fields: %s", err)
+	}
+	return string(b)
+}
+
+func TestReadImportFileErrors(t *testing.T) {
+	testData := []struct {
+		name    string
+		file    string
+		content string
+		errMsg  string
+	}{
+		{name: "unknown column", file: "a.csv", content: "summary,priority\nA,High\n", errMsg: `unknown column "priority"`},
+		{name: "missing summary", file: "a.json", content: `[{"type": "Bug"}]`, errMsg: "row 1: summary is required"},
+		{name: "duplicate id", file: "a.yaml", content: "- {id: a, summary: A}\n- {id: a, summary: B}\n", errMsg: `row 2: duplicate id "a"`},
+		{name: "own parent", file: "a.yaml", content: "- {id: a, summary: A, parent: a}\n", errMsg: "own parent"},
+		{name: "unsupported extension", file: "a.txt", content: "", errMsg: "unsupported import file extension"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadImportFile(path, IssueConfig{})
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+
+	_, err := ImportOrder([]ImportRow{{Row: 1, ID: "a", ParentID: "b"}, {Row: 2, ID: "b", ParentID: "a"}})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+}
@@ -3,21 +3,35 @@ package jt
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+
+	"github.com/leosunmo/jt/jql"
 )
 
 type JiraConfig struct {
 	URL   string
 	Email string
 	Token string
+	// DryRun, if set, makes the client print requests that would change
+	// anything in JIRA to DryRun instead of sending them. Read-only requests,
+	// such as searches and metadata lookups, are still sent.
+	DryRun io.Writer
 }
 
 type JiraClient struct {
 	c      *http.Client
 	config JiraConfig
+	// dryRunKeys counts the issues "created" in dry run mode, to give them
+	// unique placeholder keys.
+	dryRunKeys *int
 }
 
 type basicAuthTransport struct {
@@ -38,8 +52,30 @@ func NewJiraClient(conf JiraConfig) *JiraClient {
 				password: conf.Token,
 			},
 		},
-		config: conf,
+		config:     conf,
+		dryRunKeys: new(int),
+	}
+}
+
+// DryRun reports whether the client is in dry run mode.
+func (jc JiraClient) DryRun() bool {
+	return jc.config.DryRun != nil
+}
+
+// printDryRun prints the request that would have been sent in dry run mode.
+func (jc JiraClient) printDryRun(method, path string, body any) error {
+	b, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal body, %w", err)
 	}
+	_, err = fmt.Fprintf(jc.config.DryRun, "%s %s\n%s\n", method, jc.config.URL+path, b)
+	return err
+}
+
+// dryRunKey returns a placeholder key for an issue "created" in dry run mode.
+func (jc JiraClient) dryRunKey() string {
+	*jc.dryRunKeys++
+	return fmt.Sprintf("DRY-RUN-%d", *jc.dryRunKeys)
 }
 
 type IssueConfig struct {
@@ -49,6 +85,11 @@ type IssueConfig struct {
 	IssueType      string
 	ComponentNames []string
 	ParentIssueKey string
+	// Assignee is "me", an account ID, or a name or email to search for.
+	Assignee string
+	Labels   []string
+	// CustomFields are sent as is alongside the other fields, keyed by field ID.
+	CustomFields map[string]any
 }
 
 type CreateIssueRequest struct {
@@ -76,8 +117,24 @@ const (
 	FieldIssuetype   Field = "issuetype"
 	FieldComponents  Field = "components"
 	FieldParent      Field = "parent"
+	FieldLabels      Field = "labels"
+	FieldAssignee    Field = "assignee"
+	FieldStatus      Field = "status"
+	FieldPriority    Field = "priority"
+	FieldResolution  Field = "resolution"
+	FieldReporter    Field = "reporter"
+	FieldCreated     Field = "created"
+	FieldUpdated     Field = "updated"
+	FieldDueDate     Field = "duedate"
 )
 
+// CustomFieldPrefix starts the IDs of custom fields, like "customfield_10016".
+const CustomFieldPrefix = "customfield_"
+
+// TimeFormat is the format of timestamps in JIRA responses, such as
+// Fields.Created and Fields.Updated.
+const TimeFormat = "2006-01-02T15:04:05.000-0700"
+
 type Fields struct {
 	Components  []Components `json:"components,omitempty"`
 	Issuetype   Issuetype    `json:"issuetype,omitempty"`
@@ -85,6 +142,72 @@ type Fields struct {
 	Project     Project      `json:"project,omitempty"`
 	Description *Description `json:"description,omitempty"`
 	Summary     string       `json:"summary,omitempty"`
+	Labels      []string     `json:"labels,omitempty"`
+	Assignee    *User        `json:"assignee,omitempty"`
+	// The fields below are only returned by JIRA, when requested in a search.
+	Status     *Status     `json:"status,omitempty"`
+	Priority   *Priority   `json:"priority,omitempty"`
+	Resolution *Resolution `json:"resolution,omitempty"`
+	Reporter   *User       `json:"reporter,omitempty"`
+	// Created and Updated are timestamps in TimeFormat.
+	Created string `json:"created,omitempty"`
+	Updated string `json:"updated,omitempty"`
+	// DueDate is a date like "2024-10-01".
+	DueDate string `json:"duedate,omitempty"`
+	// CustomFields are extra fields, usually "customfield_XXXXX", that are sent
+	// alongside the fields above. When decoding, fields starting with
+	// CustomFieldPrefix are collected here.
+	CustomFields map[string]any `json:"-"`
+}
+
+// MarshalJSON merges the custom fields into the rest of the fields.
+func (f Fields) MarshalJSON() ([]byte, error) {
+	// Use a type without the MarshalJSON method to avoid recursion.
+	type fields Fields
+	b, err := json.Marshal(fields(f))
+	if err != nil || len(f.CustomFields) == 0 {
+		return b, err
+	}
+
+	merged := make(map[string]any)
+	if err := json.Unmarshal(b, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range f.CustomFields {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON decodes the fields, collecting custom fields in CustomFields.
+func (f *Fields) UnmarshalJSON(b []byte) error {
+	// Use a type without the UnmarshalJSON method to avoid recursion.
+	type fields Fields
+	if err := json.Unmarshal(b, (*fields)(f)); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	for k, raw := range all {
+		if !strings.HasPrefix(k, CustomFieldPrefix) {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		if v == nil {
+			continue
+		}
+		if f.CustomFields == nil {
+			f.CustomFields = make(map[string]any)
+		}
+		f.CustomFields[k] = v
+	}
+	return nil
 }
 
 type Components struct {
@@ -103,17 +226,48 @@ type Parent struct {
 }
 
 type Project struct {
-	ID  string `json:"id,omitempty"`
-	Key string `json:"key,omitempty"`
+	ID         string      `json:"id,omitempty"`
+	Key        string      `json:"key,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	IssueTypes []Issuetype `json:"issueTypes,omitempty"`
+	Components []Component `json:"components,omitempty"`
+}
+
+type Status struct {
+	ID             string          `json:"id,omitempty"`
+	Name           string          `json:"name,omitempty"`
+	StatusCategory *StatusCategory `json:"statusCategory,omitempty"`
+}
+
+type StatusCategory struct {
+	Key  string `json:"key,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type Priority struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type Resolution struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type User struct {
+	AccountID    string `json:"accountId,omitempty"`
+	DisplayName  string `json:"displayName,omitempty"`
+	EmailAddress string `json:"emailAddress,omitempty"`
 }
 type ContentBlock struct {
 	Type string `json:"type,omitempty"`
 	Text string `json:"text,omitempty"`
 }
-type Attrs struct {
-}
+type Attrs map[string]any
 type Content struct {
 	Type    string         `json:"type,omitempty"`
+	Attrs   Attrs          `json:"attrs,omitempty"`
 	Content []ContentBlock `json:"content,omitempty"`
 }
 type Description struct {
@@ -126,6 +280,12 @@ type JQLSearchRequest struct {
 	JQL            string  `json:"jql"`
 	IncludedFields []Field `json:"fields"`
 	NextPageToken  string  `json:"nextPageToken,omitempty"`
+	// Limit is the maximum number of issues to return, or 0 for all of them.
+	// Paging stops as soon as the limit is reached.
+	Limit int `json:"-"`
+	// PageSize is the number of issues to request per page, or 0 for JIRA's
+	// default. JIRA may return fewer.
+	PageSize int `json:"-"`
 }
 
 type JQLSearchResponse struct {
@@ -161,10 +321,9 @@ type CreatedIssueResponse struct {
 	Errors        map[string]string `json:"errors"`
 }
 
-// NewJIRAIssue creates a new JIRA issue using the JIRA REST API v3.
-// The function returns the key of the created issue and an error if the issue could not be created.
-// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-post
-func (jc JiraClient) NewJIRAIssue(conf IssueConfig) (string, error) {
+// BuildCreateIssueRequest builds the body of a create issue request from the
+// issue config. The assignee is looked up if set.
+func (jc JiraClient) BuildCreateIssueRequest(conf IssueConfig) (CreateIssueRequest, error) {
 	// Build the body of the request using a CreateIssueRequest
 	reqBody := CreateIssueRequest{}
 
@@ -185,137 +344,558 @@ func (jc JiraClient) NewJIRAIssue(conf IssueConfig) (string, error) {
 		reqBody.Fields.Parent = &Parent{Key: conf.ParentIssueKey}
 	}
 
+	if conf.Assignee != "" {
+		accountID, err := jc.FindAccountID(conf.Assignee)
+		if err != nil {
+			return reqBody, fmt.Errorf("failed to find assignee, %w", err)
+		}
+		reqBody.Fields.Assignee = &User{AccountID: accountID}
+	}
+
+	reqBody.Fields.Labels = conf.Labels
+	reqBody.Fields.CustomFields = conf.CustomFields
+
+	return reqBody, nil
+}
+
+// APIError is returned when JIRA rejects a request.
+type APIError struct {
+	StatusCode int
+	// Messages are the error messages from the response, with field errors
+	// formatted as "field: message".
+	Messages []string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("non-200 status %d, %s", e.StatusCode, strings.Join(e.Messages, ", "))
+}
+
+// IssueURL returns the URL to browse the issue in JIRA.
+func (jc JiraClient) IssueURL(key string) string {
+	return jc.config.URL + "/browse/" + key
+}
+
+// CreatedIssue returns the issue created from the issue config, for printing.
+// Only the ID, key and self link from JIRA's response and the fields set in
+// the issue config are populated.
+func (jc JiraClient) CreatedIssue(created CreatedIssueResponse, conf IssueConfig) Issue {
+	i := Issue{
+		ID:   created.ID,
+		Key:  created.Key,
+		Self: created.Self,
+		Fields: Fields{
+			Summary:   conf.Summary,
+			Issuetype: Issuetype{Name: conf.IssueType},
+			Project:   Project{Key: conf.ProjectKey},
+			Labels:    conf.Labels,
+		},
+	}
+	for _, name := range conf.ComponentNames {
+		i.Fields.Components = append(i.Fields.Components, Components{Name: name})
+	}
+	if conf.ParentIssueKey != "" {
+		i.Fields.Parent = &Parent{Key: conf.ParentIssueKey}
+	}
+	return i
+}
+
+// NewJIRAIssue creates a new JIRA issue using the JIRA REST API v3.
+// The function returns the ID, key and self link of the created issue and an error if the issue could not be created.
+// In dry run mode only the placeholder key is set.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-post
+func (jc JiraClient) NewJIRAIssue(conf IssueConfig) (CreatedIssueResponse, error) {
+	reqBody, err := jc.BuildCreateIssueRequest(conf)
+	if err != nil {
+		return CreatedIssueResponse{}, err
+	}
+
+	if jc.DryRun() {
+		if err := jc.printDryRun("POST", "/rest/api/3/issue", reqBody); err != nil {
+			return CreatedIssueResponse{}, err
+		}
+		return CreatedIssueResponse{Key: jc.dryRunKey()}, nil
+	}
+
 	jsonBody, err := json.MarshalIndent(reqBody, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal body, %w", err)
+		return CreatedIssueResponse{}, fmt.Errorf("failed to marshal body, %w", err)
 	}
 
 	req, err := http.NewRequest("POST", jc.config.URL+"/rest/api/3/issue", bytes.NewReader(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request, %w", err)
+		return CreatedIssueResponse{}, fmt.Errorf("failed to create request, %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
 	resp, err := jc.c.Do(req)
 	if err != nil {
-		return "", err
+		return CreatedIssueResponse{}, err
 	}
 	defer resp.Body.Close()
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read body, %w", err)
+		return CreatedIssueResponse{}, fmt.Errorf("failed to read body, %w", err)
 	}
 
 	createResponse := CreatedIssueResponse{}
 	err = json.Unmarshal(b, &createResponse)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal response, %w", err)
+		return CreatedIssueResponse{}, fmt.Errorf("failed to unmarshal response, %w", err)
 	}
 	if resp.StatusCode != http.StatusCreated {
-		var errMsg string
-		if len(createResponse.ErrorMessages) != 0 {
-			errMsg = strings.Join(createResponse.ErrorMessages, ", ")
-		} else {
-			for k, v := range createResponse.Errors {
-				errMsg = errMsg + fmt.Sprintf("%s: %s", k, v)
-			}
+		apiErr := &APIError{
+			StatusCode: resp.StatusCode,
+			Messages:   createResponse.ErrorMessages,
 		}
-		return "", fmt.Errorf("non-200 status %d, %s", resp.StatusCode, errMsg)
+		for _, k := range slices.Sorted(maps.Keys(createResponse.Errors)) {
+			apiErr.Messages = append(apiErr.Messages, fmt.Sprintf("%s: %s", k, createResponse.Errors[k]))
+		}
+		return CreatedIssueResponse{}, apiErr
 	}
 
-	return createResponse.Key, nil
+	return createResponse, nil
 }
 
-func setDescription(msg string) *Description {
-	desc := Description{
-		Type:    "doc",
-		Version: 1,
-		Content: []Content{
-			{
-				Type: "paragraph",
-				Content: []ContentBlock{
-					{
-						Type: "text",
-						Text: msg,
-					},
-				},
-			},
-		},
-	}
-	return &desc
+// BulkCreateLimit is the maximum number of issues JIRA creates per bulk request.
+const BulkCreateLimit = 50
+
+type BulkCreateRequest struct {
+	IssueUpdates []CreateIssueRequest `json:"issueUpdates"`
 }
 
-// SearchJiraIssues searches for JIRA issues using the JIRA REST API v3.
-// The function returns a slice of JQLSearchResponse and an error if the search request failed.
-func (jc JiraClient) SearchJiraIssues(jqlReq JQLSearchRequest) ([]Issue, error) {
-	var allIssues []Issue
-	var nextPageToken string
-
-	for {
-		reqBody := struct {
-			JQL           string   `json:"jql"`
-			Fields        []string `json:"fields"`
-			NextPageToken string   `json:"nextPageToken,omitempty"`
-		}{
-			JQL:           jqlReq.JQL,
-			Fields:        convertFields(jqlReq.IncludedFields),
-			NextPageToken: nextPageToken,
+type BulkCreateResponse struct {
+	Issues []CreatedIssueResponse `json:"issues"`
+	Errors []BulkCreateError      `json:"errors"`
+}
+
+type BulkCreateError struct {
+	Status              int `json:"status"`
+	FailedElementNumber int `json:"failedElementNumber"`
+	ElementErrors       struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	} `json:"elementErrors"`
+}
+
+// BulkCreateResult is the outcome of creating one of the issues in a bulk create.
+type BulkCreateResult struct {
+	// Created is the ID, key and self link of the created issue, empty if
+	// it failed. In dry run mode only the placeholder key is set.
+	Created CreatedIssueResponse
+	Err     error
+}
+
+// NewJIRAIssues creates multiple JIRA issues using the bulk create endpoint,
+// in requests of up to BulkCreateLimit issues.
+// The function returns the outcome of each issue, in the same order as the
+// issue configs. Failing issues don't stop the rest from being created.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-bulk-post
+func (jc JiraClient) NewJIRAIssues(confs []IssueConfig) []BulkCreateResult {
+	results := make([]BulkCreateResult, len(confs))
+
+	for start := 0; start < len(confs); start += BulkCreateLimit {
+		end := min(start+BulkCreateLimit, len(confs))
+
+		// Issues that fail before being sent are left out of the request, so
+		// keep track of which result each element of the request belongs to.
+		var reqBody BulkCreateRequest
+		var indexes []int
+		for i := start; i < end; i++ {
+			issue, err := jc.BuildCreateIssueRequest(confs[i])
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			reqBody.IssueUpdates = append(reqBody.IssueUpdates, issue)
+			indexes = append(indexes, i)
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		if jc.DryRun() {
+			err := jc.printDryRun("POST", "/rest/api/3/issue/bulk", reqBody)
+			for _, i := range indexes {
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Created = CreatedIssueResponse{Key: jc.dryRunKey()}
+			}
+			continue
 		}
 
-		queryResp, err := jc.doJiraSearchRequest(reqBody)
+		resp, err := jc.doBulkCreateRequest(reqBody)
 		if err != nil {
-			return nil, fmt.Errorf("search request failed: %w", err)
+			for _, i := range indexes {
+				results[i].Err = err
+			}
+			continue
 		}
 
-		allIssues = append(allIssues, queryResp.Issues...)
+		failed := make(map[int]bool, len(resp.Errors))
+		for _, e := range resp.Errors {
+			if e.FailedElementNumber < 0 || e.FailedElementNumber >= len(indexes) {
+				continue
+			}
+			apiErr := &APIError{
+				StatusCode: e.Status,
+				Messages:   e.ElementErrors.ErrorMessages,
+			}
+			for _, k := range slices.Sorted(maps.Keys(e.ElementErrors.Errors)) {
+				apiErr.Messages = append(apiErr.Messages, fmt.Sprintf("%s: %s", k, e.ElementErrors.Errors[k]))
+			}
+			results[indexes[e.FailedElementNumber]].Err = apiErr
+			failed[e.FailedElementNumber] = true
+		}
 
-		// Check for the next page token
-		if queryResp.NextPageToken == "" {
-			break // No more pages, exit the loop
+		// Created issues are returned in the order they were sent, skipping the failed ones.
+		created := resp.Issues
+		for n, i := range indexes {
+			if failed[n] {
+				continue
+			}
+			if len(created) == 0 {
+				results[i].Err = fmt.Errorf("missing from bulk create response")
+				continue
+			}
+			results[i].Created = created[0]
+			created = created[1:]
 		}
-		nextPageToken = queryResp.NextPageToken
 	}
 
-	return allIssues, nil
+	return results
 }
 
-// doJiraSearchRequest is a helper to perform the request and handle pagination token
-func (jc JiraClient) doJiraSearchRequest(reqBody interface{}) (JQLSearchResponse, error) {
-	var queryResp JQLSearchResponse
+func (jc JiraClient) doBulkCreateRequest(reqBody BulkCreateRequest) (BulkCreateResponse, error) {
+	var bulkResp BulkCreateResponse
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return queryResp, fmt.Errorf("failed to marshal body, %w", err)
+		return bulkResp, fmt.Errorf("failed to marshal body, %w", err)
 	}
 
-	req, err := http.NewRequest("POST", jc.config.URL+"/rest/api/3/search/jql", bytes.NewReader(jsonBody))
+	req, err := http.NewRequest("POST", jc.config.URL+"/rest/api/3/issue/bulk", bytes.NewReader(jsonBody))
 	if err != nil {
-		return queryResp, fmt.Errorf("failed to create request, %w", err)
+		return bulkResp, fmt.Errorf("failed to create request, %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
 	resp, err := jc.c.Do(req)
 	if err != nil {
-		return queryResp, err
+		return bulkResp, err
 	}
 	defer resp.Body.Close()
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return queryResp, fmt.Errorf("failed to read body, %w", err)
+		return bulkResp, fmt.Errorf("failed to read body, %w", err)
+	}
+
+	// JIRA responds with 400 if all issues failed, with the same body as when
+	// some of them were created.
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusBadRequest {
+		return bulkResp, fmt.Errorf("non-201 status %d\nmessage: %s", resp.StatusCode, string(b))
+	}
+
+	if err := json.Unmarshal(b, &bulkResp); err != nil {
+		return bulkResp, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if resp.StatusCode == http.StatusBadRequest && len(bulkResp.Errors) == 0 {
+		return bulkResp, fmt.Errorf("non-201 status %d\nmessage: %s", resp.StatusCode, string(b))
+	}
+
+	return bulkResp, nil
+}
+
+// GetProject returns the project with its issue types and components.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-projects/#api-rest-api-3-project-projectidorkey-get
+func (jc JiraClient) GetProject(key string) (Project, error) {
+	var p Project
+	err := jc.doGet("/rest/api/3/project/"+url.PathEscape(key), &p)
+	return p, err
+}
+
+// GetFilter returns the saved filter with its JQL.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-filters/#api-rest-api-3-filter-id-get
+func (jc JiraClient) GetFilter(id string) (Filter, error) {
+	var f Filter
+	err := jc.doGet("/rest/api/3/filter/"+url.PathEscape(id), &f)
+	return f, err
+}
+
+// JiraField is a system or custom field of the JIRA instance.
+type JiraField struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Custom bool   `json:"custom"`
+	// ClauseNames are the names the field can be used with in JQL.
+	ClauseNames []string    `json:"clauseNames"`
+	Schema      FieldSchema `json:"schema"`
+}
+
+// FieldSchema describes the type of a field. System is set for system fields
+// and Custom for custom fields, like "com.atlassian.jira.plugin.system.customfieldtypes:textfield".
+type FieldSchema struct {
+	Type   string `json:"type"`
+	Items  string `json:"items"`
+	System string `json:"system"`
+	Custom string `json:"custom"`
+}
+
+// GetFields returns the system and custom fields of the JIRA instance.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-fields/#api-rest-api-3-field-get
+func (jc JiraClient) GetFields() ([]JiraField, error) {
+	var fields []JiraField
+	err := jc.doGet("/rest/api/3/field", &fields)
+	return fields, err
+}
+
+// GetJQLAutocompleteData returns the fields, functions and reserved words
+// queries can use, for completing them.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-autocompletedata-get
+func (jc JiraClient) GetJQLAutocompleteData() (jql.AutocompleteData, error) {
+	var data jql.AutocompleteData
+	err := jc.doGet("/rest/api/3/jql/autocompletedata", &data)
+	return data, err
+}
+
+// GetJQLSuggestions returns values of the field that start with value.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-autocompletedata-suggestions-get
+func (jc JiraClient) GetJQLSuggestions(field string, value string) ([]jql.Suggestion, error) {
+	var resp struct {
+		Results []jql.Suggestion `json:"results"`
+	}
+	q := url.Values{"fieldName": {field}, "fieldValue": {value}}
+	if err := jc.doGet("/rest/api/3/jql/autocompletedata/suggestions?"+q.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	// The matching part of the display name is in bold
+	for i, s := range resp.Results {
+		resp.Results[i].Description = strings.NewReplacer("<b>", "", "</b>", "").Replace(s.Description)
+	}
+	return resp.Results, nil
+}
+
+// ValidateJQL checks the query with JIRA, which finds errors that parsing it
+// doesn't, like unknown fields and values. The errors JIRA finds are returned
+// as a *jql.ValidationError.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-parse-post
+func (jc JiraClient) ValidateJQL(query string) error {
+	var parseResp struct {
+		Queries []struct {
+			Errors []string `json:"errors"`
+		} `json:"queries"`
+	}
+	err := jc.doPost("/rest/api/3/jql/parse?validation=strict", map[string][]string{"queries": {query}}, &parseResp)
+
+	// Queries JIRA can't parse at all are rejected with the request
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return &jql.ValidationError{Query: query, Messages: apiErr.Messages}
+	}
+	if err != nil {
+		return err
+	}
+
+	var messages []string
+	for _, q := range parseResp.Queries {
+		messages = append(messages, q.Errors...)
+	}
+	if len(messages) > 0 {
+		return &jql.ValidationError{Query: query, Messages: messages}
+	}
+	return nil
+}
+
+// Myself returns the user jt is authenticated as.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-myself/#api-rest-api-3-myself-get
+func (jc JiraClient) Myself() (User, error) {
+	var u User
+	err := jc.doGet("/rest/api/3/myself", &u)
+	return u, err
+}
+
+// FindAccountID returns the account ID of a user.
+// The user can be "me" for the current user, an account ID, or a name or
+// email that matches exactly one user.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-user-search/#api-rest-api-3-user-search-get
+func (jc JiraClient) FindAccountID(user string) (string, error) {
+	if strings.EqualFold(user, "me") {
+		u, err := jc.Myself()
+		return u.AccountID, err
+	}
+
+	var users []User
+	if err := jc.doGet("/rest/api/3/user/search?query="+url.QueryEscape(user), &users); err != nil {
+		return "", err
+	}
+
+	for _, u := range users {
+		if u.AccountID == user {
+			return u.AccountID, nil
+		}
+	}
+
+	switch len(users) {
+	case 0:
+		// Account IDs aren't always searchable, so assume it's one if nothing matched.
+		if strings.Contains(user, ":") {
+			return user, nil
+		}
+		return "", fmt.Errorf("no user found matching %q", user)
+	case 1:
+		return users[0].AccountID, nil
+	default:
+		names := make([]string, len(users))
+		for i, u := range users {
+			names[i] = u.DisplayName
+		}
+		return "", fmt.Errorf("%q matches multiple users: %s", user, strings.Join(names, ", "))
+	}
+}
+
+// doGet performs a GET request against the JIRA API and decodes the response into v.
+func (jc JiraClient) doGet(path string, v any) error {
+	return jc.doRequest("GET", path, nil, v)
+}
+
+// doPost performs a POST request against the JIRA API with body as JSON and
+// decodes the response into v.
+func (jc JiraClient) doPost(path string, body any, v any) error {
+	return jc.doRequest("POST", path, body, v)
+}
+
+// doRequest sends a request to the JIRA API, with body as JSON if it's set,
+// and decodes the response into v. Responses other than 200 are returned as
+// an *APIError if JIRA sent error messages.
+func (jc JiraClient) doRequest(method string, path string, body any, v any) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal body, %w", err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, jc.config.URL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request, %w", err)
+	}
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := jc.c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body, %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return queryResp, fmt.Errorf("non-200 status %d\nmessage: %s", resp.StatusCode, string(b))
+		var errResp struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		if json.Unmarshal(b, &errResp) == nil && (len(errResp.ErrorMessages) > 0 || len(errResp.Errors) > 0) {
+			apiErr := &APIError{
+				StatusCode: resp.StatusCode,
+				Messages:   errResp.ErrorMessages,
+			}
+			for _, k := range slices.Sorted(maps.Keys(errResp.Errors)) {
+				apiErr.Messages = append(apiErr.Messages, fmt.Sprintf("%s: %s", k, errResp.Errors[k]))
+			}
+			return apiErr
+		}
+		return fmt.Errorf("non-200 status %d\nmessage: %s", resp.StatusCode, string(b))
 	}
 
-	if err := json.Unmarshal(b, &queryResp); err != nil {
-		return queryResp, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	return nil
+}
+
+// SearchJiraIssues searches for JIRA issues using the JIRA REST API v3.
+// The function returns a slice of JQLSearchResponse and an error if the search request failed.
+// Use SearchIssues to handle the issues as the pages arrive instead.
+func (jc JiraClient) SearchJiraIssues(jqlReq JQLSearchRequest) ([]Issue, error) {
+	var allIssues []Issue
+	for issue, err := range jc.SearchIssues(jqlReq) {
+		if err != nil {
+			return nil, err
+		}
+		allIssues = append(allIssues, issue)
+	}
+	return allIssues, nil
+}
+
+// SearchIssues searches for JIRA issues, yielding them as each page arrives.
+// The next page is only requested once the issues of the previous one have
+// been handled, and not at all if the loop stops early. A failed request is
+// yielded as an error, which ends the search.
+func (jc JiraClient) SearchIssues(jqlReq JQLSearchRequest) iter.Seq2[Issue, error] {
+	return func(yield func(Issue, error) bool) {
+		nextPageToken := jqlReq.NextPageToken
+		found := 0
+
+		for {
+			reqBody := struct {
+				JQL           string   `json:"jql"`
+				Fields        []string `json:"fields"`
+				NextPageToken string   `json:"nextPageToken,omitempty"`
+				MaxResults    int      `json:"maxResults,omitempty"`
+			}{
+				JQL:           jqlReq.JQL,
+				Fields:        convertFields(jqlReq.IncludedFields),
+				NextPageToken: nextPageToken,
+				MaxResults:    jqlReq.PageSize,
+			}
+			if jqlReq.Limit > 0 && (reqBody.MaxResults == 0 || reqBody.MaxResults > jqlReq.Limit-found) {
+				// Don't fetch more than what's left of the limit.
+				reqBody.MaxResults = jqlReq.Limit - found
+			}
+
+			queryResp, err := jc.doJiraSearchRequest(reqBody)
+			if err != nil {
+				yield(Issue{}, fmt.Errorf("search request failed: %w", err))
+				return
+			}
 
-	return queryResp, nil
+			for _, issue := range queryResp.Issues {
+				if !yield(issue, nil) {
+					return
+				}
+				found++
+				if jqlReq.Limit > 0 && found >= jqlReq.Limit {
+					return
+				}
+			}
+
+			// Check for the next page token
+			if queryResp.NextPageToken == "" {
+				return // No more pages
+			}
+			nextPageToken = queryResp.NextPageToken
+		}
+	}
+}
+
+// doJiraSearchRequest is a helper to perform the request and handle pagination token
+func (jc JiraClient) doJiraSearchRequest(reqBody interface{}) (JQLSearchResponse, error) {
+	var queryResp JQLSearchResponse
+	err := jc.doPost("/rest/api/3/search/jql", reqBody, &queryResp)
+	return queryResp, err
 }
 
 // convertFields converts the IncludedFields to a slice of strings
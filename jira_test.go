@@ -0,0 +1,355 @@
+package jt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leosunmo/jt/jql"
+)
+
+func TestFieldsUnmarshalJSON(t *testing.T) {
+	b := []byte(`{
+		"summary": "A bug",
+		"status": {"id": "3", "name": "In Progress", "statusCategory": {"key": "indeterminate"}},
+		"priority": {"name": "High"},
+		"created": "2024-10-01T12:34:56.000+0200",
+		"customfield_10016": 3,
+		"customfield_10020": null,
+		"customfield_10030": {"value": "Team A"}
+	}`)
+
+	var f Fields
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatalf("failed to unmarshal fields: %s", err)
+	}
+
+	if f.Summary != "A bug" || f.Status == nil || f.Status.Name != "In Progress" || f.Priority.Name != "High" {
+		t.Errorf("unexpected fields %+v", f)
+	}
+	if f.Created != "2024-10-01T12:34:56.000+0200" {
+		t.Errorf("expected created to be kept as is, got %q", f.Created)
+	}
+	if len(f.CustomFields) != 2 || f.CustomFields["customfield_10016"] != 3.0 {
+		t.Errorf("expected the two non-null custom fields, got %v", f.CustomFields)
+	}
+
+	// Custom fields are merged back when marshalling.
+	out, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("failed to marshal fields: %s", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(out, &m); err != nil {
+		t.Fatalf("failed to unmarshal fields: %s", err)
+	}
+	if m["customfield_10016"] != 3.0 {
+		t.Errorf("expected customfield_10016 in %s", out)
+	}
+}
+
+func TestSearchJiraIssuesLimit(t *testing.T) {
+	var maxResults []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			NextPageToken string `json:"nextPageToken"`
+			MaxResults    int    `json:"maxResults"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %s", err)
+		}
+		maxResults = append(maxResults, req.MaxResults)
+
+		// Pages of two issues, ignoring maxResults like JIRA may do.
+		page := len(maxResults)
+		resp := JQLSearchResponse{
+			Issues:        []Issue{{Key: fmt.Sprintf("ABC-%d", page*2-1)}, {Key: fmt.Sprintf("ABC-%d", page*2)}},
+			NextPageToken: "next",
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	issues, err := c.SearchJiraIssues(JQLSearchRequest{JQL: "project = ABC", Limit: 3})
+	if err != nil {
+		t.Fatalf("failed to search: %s", err)
+	}
+
+	if len(issues) != 3 || issues[2].Key != "ABC-3" {
+		t.Errorf("expected ABC-1 to ABC-3, got %v", issues)
+	}
+	if len(maxResults) != 2 || maxResults[0] != 3 || maxResults[1] != 1 {
+		t.Errorf("expected two requests with maxResults 3 and 1, got %v", maxResults)
+	}
+}
+
+func TestValidateJQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/3/jql/parse" || r.URL.Query().Get("validation") != "strict" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var req struct {
+			Queries []string `json:"queries"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %s", err)
+		}
+
+		var errs []string
+		if req.Queries[0] != "project = ABC" {
+			errs = []string{"Field 'stauts' does not exist or you do not have permission to view it."}
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"queries": []map[string]any{{"query": req.Queries[0], "errors": errs}},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	if err := c.ValidateJQL("project = ABC"); err != nil {
+		t.Fatalf("expected valid query, got %s", err)
+	}
+
+	err := c.ValidateJQL("project = ABC AND stauts = Open")
+	var validationErr *jql.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Messages) != 1 {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+}
+
+func TestValidateJQLRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"errorMessages": []string{"Error in the JQL Query: Expecting either a value, list or function but got 'AND'. (line 1, character 11)"},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	err := c.ValidateJQL("project = AND x = y")
+	var validationErr *jql.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Messages) != 1 {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if validationErr.Query != "project = AND x = y" {
+		t.Fatalf("expected query %q, got %q", "project = AND x = y", validationErr.Query)
+	}
+}
+
+func TestSearchIssuesStopsEarly(t *testing.T) {
+	var maxResults []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MaxResults int `json:"maxResults"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %s", err)
+		}
+		maxResults = append(maxResults, req.MaxResults)
+
+		page := len(maxResults)
+		resp := JQLSearchResponse{
+			Issues:        []Issue{{Key: fmt.Sprintf("ABC-%d", page*2-1)}, {Key: fmt.Sprintf("ABC-%d", page*2)}},
+			NextPageToken: "next",
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	var keys []string
+	for issue, err := range c.SearchIssues(JQLSearchRequest{JQL: "project = ABC", PageSize: 2}) {
+		if err != nil {
+			t.Fatalf("failed to search: %s", err)
+		}
+		keys = append(keys, issue.Key)
+		if len(keys) == 3 {
+			break
+		}
+	}
+
+	if len(keys) != 3 || keys[2] != "ABC-3" {
+		t.Errorf("expected ABC-1 to ABC-3, got %v", keys)
+	}
+	// The third page isn't requested after stopping
+	if len(maxResults) != 2 || maxResults[0] != 2 || maxResults[1] != 2 {
+		t.Errorf("expected two requests with maxResults 2, got %v", maxResults)
+	}
+}
+
+func TestNewJIRAIssueCreatedIssue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id": "10042", "key": "ABC-7", "self": "%s/rest/api/3/issue/10042"}`, "http://"+r.Host)
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	conf := IssueConfig{Summary: "Fix the build", ProjectKey: "ABC", IssueType: "Task"}
+	created, err := c.NewJIRAIssue(conf)
+	if err != nil {
+		t.Fatalf("failed to create issue: %s", err)
+	}
+
+	issue := c.CreatedIssue(created, conf)
+	if issue.ID != "10042" || issue.Key != "ABC-7" || issue.Self != srv.URL+"/rest/api/3/issue/10042" {
+		t.Fatalf("expected the ID, key and self link from the response, got %q, %q and %q", issue.ID, issue.Key, issue.Self)
+	}
+	if issue.Fields.Summary != conf.Summary {
+		t.Fatalf("expected summary %q, got %q", conf.Summary, issue.Fields.Summary)
+	}
+}
+
+func TestNewJIRAIssueDryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s in dry run", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	c := NewJiraClient(JiraConfig{URL: srv.URL, DryRun: &out})
+	created, err := c.NewJIRAIssue(IssueConfig{Summary: "Fix the build", ProjectKey: "ABC", IssueType: "Task"})
+	if err != nil {
+		t.Fatalf("failed to create issue: %s", err)
+	}
+	if created.Key != "DRY-RUN-1" {
+		t.Fatalf("expected key %q, got %q", "DRY-RUN-1", created.Key)
+	}
+
+	line, body, _ := strings.Cut(out.String(), "\n")
+	if want := "POST " + srv.URL + "/rest/api/3/issue"; line != want {
+		t.Fatalf("expected %q, got %q", want, line)
+	}
+	var req CreateIssueRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode printed body: %s", err)
+	}
+	if req.Fields.Summary != "Fix the build" {
+		t.Fatalf("expected summary %q, got %q", "Fix the build", req.Fields.Summary)
+	}
+}
+
+func TestNewJIRAIssuesDryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request to %s in dry run", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	c := NewJiraClient(JiraConfig{URL: srv.URL, DryRun: &out})
+	results := c.NewJIRAIssues([]IssueConfig{
+		{Summary: "First", ProjectKey: "ABC", IssueType: "Task"},
+		{Summary: "Second", ProjectKey: "ABC", IssueType: "Task"},
+	})
+	for i, res := range results {
+		if res.Err != nil {
+			t.Fatalf("failed to create issue %d: %s", i+1, res.Err)
+		}
+		if want := fmt.Sprintf("DRY-RUN-%d", i+1); res.Created.Key != want {
+			t.Fatalf("expected key %q, got %q", want, res.Created.Key)
+		}
+	}
+
+	line, body, _ := strings.Cut(out.String(), "\n")
+	if want := "POST " + srv.URL + "/rest/api/3/issue/bulk"; line != want {
+		t.Fatalf("expected %q, got %q", want, line)
+	}
+	var req BulkCreateRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode printed body: %s", err)
+	}
+	if len(req.IssueUpdates) != 2 || req.IssueUpdates[1].Fields.Summary != "Second" {
+		t.Fatalf("expected both issues in the printed body, got %+v", req.IssueUpdates)
+	}
+}
+
+func TestNewJIRAIssuesFailedElements(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/api/3/user/search" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		var req BulkCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %s", err)
+		}
+		if len(req.IssueUpdates) != 3 {
+			t.Errorf("expected 3 issues in the request, got %d", len(req.IssueUpdates))
+		}
+
+		// The second issue of the request is the third input, as the
+		// second input fails before being sent.
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{
+			"issues": [{"id": "1", "key": "ABC-1"}, {"id": "2", "key": "ABC-2"}],
+			"errors": [{"status": 400, "failedElementNumber": 1, "elementErrors": {"errors": {"summary": "Summary is required."}}}]
+		}`)
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	results := c.NewJIRAIssues([]IssueConfig{
+		{Summary: "First", ProjectKey: "ABC", IssueType: "Task"},
+		{Summary: "Second", ProjectKey: "ABC", IssueType: "Task", Assignee: "nobody"},
+		{ProjectKey: "ABC", IssueType: "Task"},
+		{Summary: "Fourth", ProjectKey: "ABC", IssueType: "Task"},
+	})
+
+	if results[0].Err != nil || results[0].Created.Key != "ABC-1" {
+		t.Fatalf("expected the first issue to be created as ABC-1, got %q, %v", results[0].Created.Key, results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Fatalf("expected the second issue to fail to find the assignee")
+	}
+	var apiErr *APIError
+	if !errors.As(results[2].Err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a 400 API error for the third issue, got %v", results[2].Err)
+	}
+	if len(apiErr.Messages) != 1 || apiErr.Messages[0] != "summary: Summary is required." {
+		t.Fatalf("expected %q, got %q", "summary: Summary is required.", apiErr.Messages)
+	}
+	if results[3].Err != nil || results[3].Created.Key != "ABC-2" {
+		t.Fatalf("expected the fourth issue to be created as ABC-2, got %q, %v", results[3].Created.Key, results[3].Err)
+	}
+}
+
+func TestNewJIRAIssuesAllFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// JIRA responds with 400 when none of the issues were created.
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{
+			"issues": [],
+			"errors": [
+				{"status": 400, "failedElementNumber": 0, "elementErrors": {"errorMessages": ["Project is archived."]}},
+				{"status": 400, "failedElementNumber": 1, "elementErrors": {"errorMessages": ["Project is archived."]}}
+			]
+		}`)
+	}))
+	defer srv.Close()
+
+	c := NewJiraClient(JiraConfig{URL: srv.URL})
+	results := c.NewJIRAIssues([]IssueConfig{
+		{Summary: "First", ProjectKey: "ABC", IssueType: "Task"},
+		{Summary: "Second", ProjectKey: "ABC", IssueType: "Task"},
+	})
+
+	for i, res := range results {
+		var apiErr *APIError
+		if !errors.As(res.Err, &apiErr) {
+			t.Fatalf("expected an API error for issue %d, got %v", i+1, res.Err)
+		}
+		if len(apiErr.Messages) != 1 || apiErr.Messages[0] != "Project is archived." {
+			t.Fatalf("expected %q, got %q", "Project is archived.", apiErr.Messages)
+		}
+		if res.Created.Key != "" {
+			t.Fatalf("expected no key for issue %d, got %q", i+1, res.Created.Key)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package jql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AutocompleteData is the fields, functions and reserved words of a JIRA
+// instance, used to complete queries.
+// https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-autocompletedata-get
+type AutocompleteData struct {
+	Fields        []AutocompleteField    `json:"visibleFieldNames"`
+	Functions     []AutocompleteFunction `json:"visibleFunctionNames"`
+	ReservedWords []string               `json:"jqlReservedWords"`
+}
+
+// AutocompleteField is a field that can be used in queries. The flags are
+// "true" or "false", as JIRA returns them.
+type AutocompleteField struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"displayName"`
+	Orderable   string `json:"orderable"`
+	Searchable  string `json:"searchable"`
+	// Auto is "true" if JIRA can suggest values for the field.
+	Auto string `json:"auto"`
+	// CfID is the cf[N] name of custom fields.
+	CfID      string   `json:"cfid"`
+	Operators []string `json:"operators"`
+	Types     []string `json:"types"`
+}
+
+// AutocompleteFunction is a function that can be used as a value.
+type AutocompleteFunction struct {
+	Value       string `json:"value"`
+	DisplayName string `json:"displayName"`
+	// IsList is "true" if the function returns a list, for IN.
+	IsList string   `json:"isList"`
+	Types  []string `json:"types"`
+}
+
+// Suggestion is a suggested token for a partial query.
+type Suggestion struct {
+	Value       string `json:"value"`
+	Description string `json:"displayName"`
+}
+
+// LoadAutocompleteData returns the autocomplete data cached in the file if
+// it's newer than maxAge, and otherwise fetches it and caches it in the file.
+// If fetching fails, an outdated cache is used instead.
+func LoadAutocompleteData(path string, maxAge time.Duration, fetch func() (AutocompleteData, error)) (AutocompleteData, error) {
+	var cached AutocompleteData
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		b, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(b, &cached)
+		}
+		if err == nil && time.Since(info.ModTime()) < maxAge {
+			return cached, nil
+		}
+		statErr = err
+	}
+
+	data, err := fetch()
+	if err != nil {
+		if statErr == nil {
+			return cached, nil
+		}
+		return data, fmt.Errorf("failed to get autocomplete data: %w", err)
+	}
+
+	// The cache only saves requests, so failing to write it isn't an error.
+	if b, err := json.Marshal(data); err == nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			_ = os.WriteFile(path, b, 0o600)
+		}
+	}
+	return data, nil
+}
+
+// Completer suggests the next token of partial queries.
+type Completer struct {
+	data AutocompleteData
+	// fields are the fields by their lower case name, and cf[N] name.
+	fields map[string]AutocompleteField
+	values func(field string, prefix string) ([]Suggestion, error)
+}
+
+// NewCompleter returns a completer for the autocomplete data. values returns
+// suggested values for a field that start with prefix, and is only called
+// for fields JIRA can suggest values for. It can be nil.
+func NewCompleter(data AutocompleteData, values func(field string, prefix string) ([]Suggestion, error)) *Completer {
+	c := &Completer{data: data, fields: make(map[string]AutocompleteField), values: values}
+	for _, f := range data.Fields {
+		c.fields[strings.ToLower(unquoteField(f.Value))] = f
+		if f.CfID != "" {
+			c.fields[strings.ToLower(f.CfID)] = f
+		}
+	}
+	return c
+}
+
+// completeState is what a partial query expects next.
+type completeState int
+
+const (
+	expectField completeState = iota
+	expectOperator
+	expectValue
+	// expectList is after IN, before the list of values or a function.
+	expectList
+	inList
+	inFunction
+	afterClause
+	expectBy
+	expectOrderField
+	afterOrderField
+)
+
+// completeContext is where a partial query ends.
+type completeContext struct {
+	state    completeState
+	field    string
+	operator string
+}
+
+// Suggest returns suggestions for the token at the end of the query, which
+// can be partially written, and the position in the query the suggestions
+// replace from. Suggestions already written partially are matched ignoring
+// case.
+func (c *Completer) Suggest(query string) (int, []Suggestion, error) {
+	tokens, err := lex(query)
+	partial := token{kind: wordToken, pos: len(query)}
+	var se *syntaxError
+	switch {
+	case errors.As(err, &se) && se.pos < len(query) && (query[se.pos] == '"' || query[se.pos] == '\''):
+		// The last token is a string that's still being written
+		tokens, err = lex(query[:se.pos])
+		if err != nil {
+			return 0, nil, err
+		}
+		partial = token{kind: stringToken, text: query[se.pos+1:], pos: se.pos}
+		tokens = tokens[:len(tokens)-1]
+	case err != nil:
+		return 0, nil, err
+	default:
+		tokens = tokens[:len(tokens)-1]
+		// The last word is still being written if nothing follows it
+		if n := len(tokens); n > 0 && tokens[n-1].kind == wordToken && !strings.ContainsAny(query[len(query)-1:], delimiters) {
+			partial = tokens[n-1]
+			tokens = tokens[:n-1]
+		}
+	}
+
+	suggestions, err := c.suggestions(completeAt(tokens), partial.text)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var matched []Suggestion
+	prefix := strings.ToLower(strings.Trim(partial.text, `"'`))
+	for _, s := range suggestions {
+		if strings.HasPrefix(strings.ToLower(strings.Trim(s.Value, `"'`)), prefix) {
+			matched = append(matched, s)
+		}
+	}
+
+	// Separate the suggestions from a token they'd otherwise stick to
+	if partial.text == "" && partial.kind == wordToken && strings.ContainsAny(query[max(len(query)-1, 0):], `"')`) {
+		for i := range matched {
+			matched[i].Value = " " + matched[i].Value
+		}
+	}
+	return partial.pos, matched, nil
+}
+
+// completeAt returns where the tokens of a partial query end.
+func completeAt(tokens []token) completeContext {
+	var ctx completeContext
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		isFunction := t.kind == wordToken && i+1 < len(tokens) && tokens[i+1].kind == lparenToken
+		if isFunction && (ctx.state == expectValue || ctx.state == expectList || ctx.state == inList) {
+			// Skip the arguments, which can't be completed
+			for i += 2; i < len(tokens) && tokens[i].kind != rparenToken; i++ {
+			}
+			switch {
+			case i == len(tokens):
+				ctx.state = inFunction
+			case ctx.state != inList:
+				ctx.state = afterClause
+			}
+			continue
+		}
+
+		switch ctx.state {
+		case expectField:
+			switch {
+			case isWord(t, "NOT"), t.kind == lparenToken:
+			case isWord(t, "ORDER"):
+				ctx.state = expectBy
+			default:
+				ctx = completeContext{state: expectOperator, field: t.text}
+			}
+		case expectOperator:
+			switch {
+			case t.kind == operatorToken:
+				ctx.operator, ctx.state = t.text, expectValue
+			case isWord(t, "NOT"):
+				ctx.operator = "NOT"
+			case isWord(t, "IN"):
+				ctx.operator, ctx.state = strings.TrimSpace(ctx.operator+" IN"), expectList
+			case isWord(t, "IS", "WAS"):
+				ctx.operator, ctx.state = strings.ToUpper(t.text), expectValue
+			case isWord(t, "CHANGED"):
+				ctx.operator, ctx.state = "CHANGED", afterClause
+			}
+		case expectValue:
+			switch {
+			case isWord(t, "NOT") && (ctx.operator == "IS" || ctx.operator == "WAS"):
+				ctx.operator += " NOT"
+			case isWord(t, "IN") && strings.HasPrefix(ctx.operator, "WAS"):
+				ctx.operator, ctx.state = ctx.operator+" IN", expectList
+			default:
+				ctx.state = afterClause
+			}
+		case expectList:
+			ctx.state = afterClause
+			if t.kind == lparenToken {
+				ctx.state = inList
+			}
+		case inList:
+			if t.kind == rparenToken {
+				ctx.state = afterClause
+			}
+		case afterClause:
+			switch {
+			case isWord(t, "AND", "OR"):
+				ctx = completeContext{state: expectField}
+			case isWord(t, "ORDER"):
+				ctx.state = expectBy
+			case t.kind == wordToken && slices.Contains(historyPredicates[ctx.operator], strings.ToUpper(t.text)):
+				// Predicate values aren't values of the field
+				ctx.field, ctx.state = "", expectValue
+				if strings.EqualFold(t.text, "DURING") {
+					ctx.state = expectList
+				}
+			}
+		case expectBy:
+			if isWord(t, "BY") {
+				ctx.state = expectOrderField
+			}
+		case expectOrderField:
+			ctx.state = afterOrderField
+		case afterOrderField:
+			if t.kind == commaToken {
+				ctx.state = expectOrderField
+			}
+		}
+	}
+	return ctx
+}
+
+// suggestions returns everything that can follow in the context.
+func (c *Completer) suggestions(ctx completeContext, prefix string) ([]Suggestion, error) {
+	var s []Suggestion
+	switch ctx.state {
+	case expectField:
+		s = c.fieldSuggestions(func(f AutocompleteField) bool { return f.Searchable != "false" })
+		s = append(s, Suggestion{Value: Not.String()})
+	case expectOperator:
+		operators := []string{"=", "!=", "~", "!~", ">", ">=", "<", "<=", "in", "not in", "is", "is not", "was", "was in", "was not", "was not in", "changed"}
+		if f, ok := c.field(ctx.field); ok && len(f.Operators) > 0 {
+			operators = f.Operators
+		}
+		if ctx.operator == "NOT" {
+			operators = []string{"in"}
+		}
+		for _, op := range operators {
+			s = append(s, Suggestion{Value: strings.ToUpper(op)})
+		}
+	case expectValue, inList:
+		if ctx.operator == "IS" || ctx.operator == "IS NOT" {
+			return []Suggestion{{Value: "EMPTY"}, {Value: "NULL"}}, nil
+		}
+		s = c.functionSuggestions(ctx.field, false)
+		f, ok := c.field(ctx.field)
+		if ok && f.Auto == "true" && c.values != nil {
+			values, err := c.values(unquoteField(f.Value), strings.Trim(prefix, `"'`))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get suggestions for %s: %w", ctx.field, err)
+			}
+			for _, v := range values {
+				s = append(s, Suggestion{Value: completeValue(v.Value), Description: v.Description})
+			}
+		}
+	case expectList:
+		s = append([]Suggestion{{Value: "("}}, c.functionSuggestions(ctx.field, true)...)
+	case afterClause:
+		s = []Suggestion{{Value: And.String()}, {Value: Or.String()}, {Value: OrderByKeyword.String()}}
+		for _, p := range historyPredicates[ctx.operator] {
+			s = append(s, Suggestion{Value: p})
+		}
+	case expectBy:
+		s = []Suggestion{{Value: "BY"}}
+	case expectOrderField:
+		s = c.fieldSuggestions(func(f AutocompleteField) bool { return f.Orderable == "true" })
+	case afterOrderField:
+		s = []Suggestion{{Value: "ASC"}, {Value: "DESC"}}
+	}
+	return s, nil
+}
+
+// field returns the field with the name as written in the query.
+func (c *Completer) field(name string) (AutocompleteField, bool) {
+	f, ok := c.fields[strings.ToLower(unquoteField(name))]
+	return f, ok
+}
+
+func (c *Completer) fieldSuggestions(include func(AutocompleteField) bool) []Suggestion {
+	var s []Suggestion
+	for _, f := range c.data.Fields {
+		if include(f) {
+			s = append(s, Suggestion{Value: quoteField(unquoteField(f.Value)), Description: f.DisplayName})
+		}
+	}
+	return s
+}
+
+// functionSuggestions returns the functions returning the type of the field,
+// or lists of it.
+func (c *Completer) functionSuggestions(field string, list bool) []Suggestion {
+	f, known := c.field(field)
+	var s []Suggestion
+	for _, fn := range c.data.Functions {
+		if (fn.IsList == "true") != list {
+			continue
+		}
+		if known && len(f.Types) > 0 && !slices.ContainsFunc(fn.Types, func(t string) bool { return slices.Contains(f.Types, t) }) {
+			continue
+		}
+		s = append(s, Suggestion{Value: fn.Value, Description: fn.DisplayName})
+	}
+	return s
+}
+
+// plainValueRe matches values that don't need quoting.
+var plainValueRe = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// completeValue returns a suggested value quoted if it needs to be.
+func completeValue(v string) string {
+	if plainValueRe.MatchString(v) && !reservedWords[strings.ToLower(v)] {
+		return v
+	}
+	return quoteValue(v)
+}
+
+// unquoteField removes the double quotes JIRA puts around some field names.
+func unquoteField(name string) string {
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return strings.ReplaceAll(name[1:len(name)-1], `\"`, `"`)
+	}
+	return name
+}
@@ -0,0 +1,174 @@
+package jql
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var testAutocompleteData = AutocompleteData{
+	Fields: []AutocompleteField{
+		{Value: "project", Orderable: "true", Searchable: "true", Auto: "true", Operators: []string{"=", "!=", "in", "not in", "is", "is not"}, Types: []string{"com.atlassian.jira.project.Project"}},
+		{Value: "status", Orderable: "true", Searchable: "true", Auto: "true", Operators: []string{"=", "!=", "in", "not in", "was", "changed"}, Types: []string{"com.atlassian.jira.issue.status.Status"}},
+		{Value: "assignee", Orderable: "true", Searchable: "true", Auto: "true", Operators: []string{"=", "!=", "in", "is", "was"}, Types: []string{"com.atlassian.jira.user.ApplicationUser"}},
+		{Value: "summary", Orderable: "true", Searchable: "true", Operators: []string{"~", "!~", "is", "is not"}, Types: []string{"java.lang.String"}},
+		{Value: `"Story Points"`, DisplayName: "Story Points - cf[10016]", Orderable: "true", Searchable: "true", CfID: "cf[10016]", Operators: []string{"=", ">", "<"}, Types: []string{"java.lang.Number"}},
+	},
+	Functions: []AutocompleteFunction{
+		{Value: "currentUser()", DisplayName: "currentUser()", IsList: "false", Types: []string{"com.atlassian.jira.user.ApplicationUser"}},
+		{Value: "membersOf(\"\")", DisplayName: "membersOf(Group)", IsList: "true", Types: []string{"com.atlassian.jira.user.ApplicationUser"}},
+		{Value: "projectsLeadByUser()", DisplayName: "projectsLeadByUser()", IsList: "true", Types: []string{"com.atlassian.jira.project.Project"}},
+	},
+}
+
+func testValues(field, prefix string) ([]Suggestion, error) {
+	switch field {
+	case "project":
+		return []Suggestion{{Value: "ABC", Description: "Alphabet"}, {Value: "ABD", Description: "Abdominal"}}, nil
+	case "status":
+		return []Suggestion{{Value: "Open"}, {Value: "In Progress"}}, nil
+	}
+	return nil, nil
+}
+
+func TestSuggest(t *testing.T) {
+	testData := []struct {
+		name     string
+		query    string
+		start    int
+		expected []string
+	}{
+		{
+			name:     "empty query",
+			query:    "",
+			expected: []string{"project", "status", "assignee", "summary", `"Story Points"`, "NOT"},
+		},
+		{
+			name:     "partial field",
+			query:    "project = ABC AND st",
+			start:    18,
+			expected: []string{"status", `"Story Points"`},
+		},
+		{
+			name:     "operators of the field",
+			query:    "summary ",
+			start:    8,
+			expected: []string{"~", "!~", "IS", "IS NOT"},
+		},
+		{
+			name:     "values of the field",
+			query:    "project = ab",
+			start:    10,
+			expected: []string{"ABC", "ABD"},
+		},
+		{
+			name:     "quoted value",
+			query:    `status = "in`,
+			start:    9,
+			expected: []string{"'In Progress'"},
+		},
+		{
+			name:     "functions of the field",
+			query:    "assignee = cu",
+			start:    11,
+			expected: []string{"currentUser()"},
+		},
+		{
+			name:     "list functions",
+			query:    "assignee in ",
+			start:    12,
+			expected: []string{"(", `membersOf("")`},
+		},
+		{
+			name:     "values in a list",
+			query:    "project in (ABC, ",
+			start:    17,
+			expected: []string{"ABC", "ABD"},
+		},
+		{
+			name:     "empty",
+			query:    "assignee is ",
+			start:    12,
+			expected: []string{"EMPTY", "NULL"},
+		},
+		{
+			name:     "after a clause",
+			query:    `status = "Open"`,
+			start:    15,
+			expected: []string{" AND", " OR", " ORDER BY"},
+		},
+		{
+			name:     "history predicates",
+			query:    "status changed ",
+			start:    15,
+			expected: []string{"AND", "OR", "ORDER BY", "AFTER", "BEFORE", "BY", "DURING", "ON", "FROM", "TO"},
+		},
+		{
+			name:     "after a function",
+			query:    "assignee = currentUser() o",
+			start:    25,
+			expected: []string{"OR", "ORDER BY"},
+		},
+		{
+			name:     "ORDER BY fields",
+			query:    "project = ABC ORDER BY sum",
+			start:    23,
+			expected: []string{"summary"},
+		},
+		{
+			name:     "ORDER BY direction",
+			query:    "ORDER BY created ",
+			start:    17,
+			expected: []string{"ASC", "DESC"},
+		},
+	}
+
+	c := NewCompleter(testAutocompleteData, testValues)
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			start, suggestions, err := c.Suggest(tt.query)
+			if err != nil {
+				t.Fatalf("failed to suggest: %s", err)
+			}
+			var values []string
+			for _, s := range suggestions {
+				values = append(values, s.Value)
+			}
+			if start != tt.start || !slices.Equal(values, tt.expected) {
+				t.Fatalf("expected %q at %d, got %q at %d", tt.expected, tt.start, values, start)
+			}
+		})
+	}
+}
+
+func TestLoadAutocompleteData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "autocomplete.json")
+	fetches := 0
+	fetch := func() (AutocompleteData, error) {
+		fetches++
+		return testAutocompleteData, nil
+	}
+
+	for range 2 {
+		data, err := LoadAutocompleteData(path, time.Hour, fetch)
+		if err != nil {
+			t.Fatalf("failed to load autocomplete data: %s", err)
+		}
+		if len(data.Fields) != len(testAutocompleteData.Fields) {
+			t.Fatalf("expected %d fields, got %d", len(testAutocompleteData.Fields), len(data.Fields))
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("expected the data to be fetched once, got %d", fetches)
+	}
+
+	// Outdated data is used if it can't be fetched again
+	data, err := LoadAutocompleteData(path, 0, func() (AutocompleteData, error) {
+		return AutocompleteData{}, errors.New("offline")
+	})
+	if err != nil || len(data.Fields) == 0 {
+		t.Fatalf("expected the cached data, got %v", err)
+	}
+}
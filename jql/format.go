@@ -0,0 +1,142 @@
+package jql
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// lineWidth is the width Format keeps lines within when it can.
+const lineWidth = 80
+
+// indent is the indentation of the clauses of split groups.
+const indent = "  "
+
+// Format returns the query formatted for reading, with the keywords in upper
+// case and values quoted the same way as the builder does: plain words are
+// quoted, apart from numbers, relative dates and function arguments. Queries
+// longer than a line are split with each AND, OR and ORDER BY starting a new
+// line, and groups that don't fit on their line are split the same way,
+// indented.
+//
+//	project = 'ABC'
+//	AND (
+//	  status = 'Open'
+//	  OR assignee = currentUser()
+//	)
+//	ORDER BY created DESC
+func (q *JQLQueryBuilder) Format() (string, error) {
+	s, err := q.Build()
+	if err != nil || len(q.qt) == 0 {
+		return s, err
+	}
+
+	words := quoteWords(q.words())
+	var b strings.Builder
+	writeWords(&b, words, false)
+	if b.Len() <= lineWidth {
+		return b.String(), nil
+	}
+
+	b.Reset()
+	formatWords(&b, words, "")
+	return b.String(), nil
+}
+
+// numberRe matches numbers, which are left unquoted like relative dates.
+var numberRe = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// quoteWords returns the words with the plain word values of their clauses
+// quoted, recursing into groups.
+func quoteWords(words []jqlWord) []jqlWord {
+	quoted := make([]jqlWord, len(words))
+	for i, w := range words {
+		switch w := w.(type) {
+		case Group:
+			w.words = quoteWords(w.words)
+			quoted[i] = w
+		case Operator:
+			w.value = quoteValues(w.value)
+			w.predicates = slices.Clone(w.predicates)
+			for n, p := range w.predicates {
+				// Predicates are the name followed by their values
+				name, values, _ := strings.Cut(p, " ")
+				w.predicates[n] = name + " " + quoteValues(values)
+			}
+			quoted[i] = w
+		default:
+			quoted[i] = w
+		}
+	}
+	return quoted
+}
+
+// quoteValues returns the values of a clause, as written by the parser or
+// the builder, with plain words quoted. Function calls, EMPTY and NULL,
+// numbers and relative dates are kept as they are.
+func quoteValues(values string) string {
+	tokens, err := lex(values)
+	if err != nil {
+		return values
+	}
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind != wordToken {
+			continue
+		}
+		if tokens[i+1].kind == lparenToken {
+			// Skip the arguments of the function
+			for depth := 0; i+1 < len(tokens); i++ {
+				if tokens[i+1].kind == lparenToken {
+					depth++
+				} else if tokens[i+1].kind == rparenToken {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			continue
+		}
+		if isWord(t, "EMPTY", "NULL") || numberRe.MatchString(t.text) || relativeDateRe.MatchString(t.text) {
+			continue
+		}
+		b.WriteString(values[last:t.pos])
+		b.WriteString(quoteValue(t.text))
+		last = t.pos + len(t.text)
+	}
+	b.WriteString(values[last:])
+	return b.String()
+}
+
+// formatWords writes the words with each AND, OR and ORDER BY on a new line
+// starting with prefix, splitting groups that don't fit on their line.
+func formatWords(b *strings.Builder, words []jqlWord, prefix string) {
+	for _, w := range words {
+		switch w := w.(type) {
+		case Group:
+			s := w.String()
+			column := b.Len() - strings.LastIndex(b.String(), "\n") - 1
+			if column+len(s) <= lineWidth {
+				b.WriteString(s)
+				continue
+			}
+			b.WriteString("(\n" + prefix + indent)
+			formatWords(b, w.words, prefix+indent)
+			b.WriteString("\n" + prefix + ")")
+		case OrderBy:
+			b.WriteString("\n" + prefix + w.String())
+		default:
+			switch w.Type() {
+			case keywordType:
+				b.WriteString("\n" + prefix + w.String() + " ")
+			case prefixKeywordType:
+				b.WriteString(w.String() + " ")
+			default:
+				b.WriteString(w.String())
+			}
+		}
+	}
+}
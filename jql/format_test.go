@@ -0,0 +1,70 @@
+package jql
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	testData := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "short query",
+			query:    `project = abc and status in ("Open", "To Do") order by created desc`,
+			expected: `project = 'abc' AND status IN ('Open', 'To Do') ORDER BY created DESC`,
+		},
+		{
+			name:  "quoting",
+			query: `key in (PRJ-1, "PRJ-2") and "Story Points" > 3 and assignee is not empty and issue in linkedIssues(PRJ-3, blocks) and status was Open by alice before -1w`,
+			expected: `key IN ('PRJ-1', 'PRJ-2')
+AND "Story Points" > 3
+AND assignee IS NOT EMPTY
+AND issue IN linkedIssues(PRJ-3, blocks)
+AND status WAS 'Open' BY 'alice' BEFORE -1w`,
+		},
+		{
+			name:  "long query",
+			query: `project = ABC and (status = "In Progress" or assignee = currentUser()) and not type = Bug and updated >= -7d order by priority desc, created`,
+			expected: `project = 'ABC'
+AND (status = 'In Progress' OR assignee = currentUser())
+AND NOT type = 'Bug'
+AND updated >= -7d
+ORDER BY priority DESC, created`,
+		},
+		{
+			name:  "long group",
+			query: `project = ABC and (summary ~ "a rather long search term" or description ~ "another rather long search term") order by created`,
+			expected: `project = 'ABC'
+AND (
+  summary ~ 'a rather long search term'
+  OR description ~ 'another rather long search term'
+)
+ORDER BY created`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %s", err)
+			}
+			s, err := q.Format()
+			if err != nil {
+				t.Fatalf("failed to format query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tt.expected, s)
+			}
+
+			// The formatted query parses to the same query
+			q, err = Parse(s)
+			if err != nil {
+				t.Fatalf("failed to parse formatted query: %s", err)
+			}
+			if again, _ := q.Format(); again != s {
+				t.Fatalf("expected formatting to be stable, got:\n%s", again)
+			}
+		})
+	}
+}
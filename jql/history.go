@@ -0,0 +1,147 @@
+package jql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// History operators search the past values of a field, and can be narrowed
+// down with predicates, like status WAS 'Open' BY 'alice' BEFORE '2024-10-01'.
+var (
+	wasPredicates     = []string{"AFTER", "BEFORE", "BY", "DURING", "ON"}
+	changedPredicates = []string{"AFTER", "BEFORE", "BY", "DURING", "ON", "FROM", "TO"}
+)
+
+// historyPredicates are the predicates each history operator supports.
+var historyPredicates = map[string][]string{
+	"WAS":        wasPredicates,
+	"WAS NOT":    wasPredicates,
+	"WAS IN":     wasPredicates,
+	"WAS NOT IN": wasPredicates,
+	"CHANGED":    changedPredicates,
+}
+
+// Was matches issues where the field had the value at some point.
+//
+// status WAS 'In Progress'
+func (q *JQLQueryBuilder) Was(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "WAS", value: quoteValue(value)})
+	return q
+}
+
+// WasNot matches issues where the field never had the value.
+func (q *JQLQueryBuilder) WasNot(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "WAS NOT", value: quoteValue(value)})
+	return q
+}
+
+// WasIn matches issues where the field had any of the values at some point.
+//
+// status WAS IN ('Open', 'Reopened')
+func (q *JQLQueryBuilder) WasIn(field string, values ...string) *JQLQueryBuilder {
+	q.qt = append(q.qt, listOperator(field, "WAS IN", values))
+	return q
+}
+
+// WasNotIn matches issues where the field never had any of the values.
+func (q *JQLQueryBuilder) WasNotIn(field string, values ...string) *JQLQueryBuilder {
+	q.qt = append(q.qt, listOperator(field, "WAS NOT IN", values))
+	return q
+}
+
+// Changed matches issues where the field changed, usually narrowed down with
+// predicates.
+//
+// status CHANGED FROM 'Open' TO 'Done'
+func (q *JQLQueryBuilder) Changed(field string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "CHANGED"})
+	return q
+}
+
+// From narrows CHANGED down to changes from the value.
+func (q *JQLQueryBuilder) From(value Value) *JQLQueryBuilder {
+	return q.predicate("FROM", value)
+}
+
+// To narrows CHANGED down to changes to the value.
+func (q *JQLQueryBuilder) To(value Value) *JQLQueryBuilder {
+	return q.predicate("TO", value)
+}
+
+// By narrows a history operator down to changes by the user, like
+// String("alice") or CurrentUser().
+func (q *JQLQueryBuilder) By(user Value) *JQLQueryBuilder {
+	return q.predicate("BY", user)
+}
+
+// After narrows a history operator down to changes after the date, like
+// String("2024-10-01"), RelativeDate("-7d") or StartOfWeek().
+func (q *JQLQueryBuilder) After(date Value) *JQLQueryBuilder {
+	return q.predicate("AFTER", date)
+}
+
+// Before narrows a history operator down to changes before the date.
+func (q *JQLQueryBuilder) Before(date Value) *JQLQueryBuilder {
+	return q.predicate("BEFORE", date)
+}
+
+// On narrows a history operator down to changes on the date.
+func (q *JQLQueryBuilder) On(date Value) *JQLQueryBuilder {
+	return q.predicate("ON", date)
+}
+
+// During narrows a history operator down to changes between the dates.
+//
+// status WAS 'Open' DURING ('2024-01-01', startOfMonth())
+func (q *JQLQueryBuilder) During(from Value, to Value) *JQLQueryBuilder {
+	return q.predicate("DURING", from, to)
+}
+
+// predicate adds the predicate to the history operator before it. If there's
+// no history operator before it, it doesn't support the predicate, or the
+// values are invalid, the error is reported by Build.
+// Multiple values are rendered in parentheses, like DURING ('a', 'b').
+func (q *JQLQueryBuilder) predicate(name string, values ...Value) *JQLQueryBuilder {
+	var valueErr error
+	rendered := make([]string, len(values))
+	for i, v := range values {
+		s, err := v.jql()
+		if err != nil && valueErr == nil {
+			valueErr = err
+		}
+		rendered[i] = s
+	}
+	value := rendered[0]
+	if len(rendered) > 1 {
+		value = "(" + strings.Join(rendered, ", ") + ")"
+	}
+	pred := name + " " + value
+
+	var op Operator
+	var ok bool
+	if len(q.qt) > 0 {
+		op, ok = q.qt[len(q.qt)-1].(Operator)
+	}
+	if !ok {
+		// Keep the predicate as a word of its own, to point at it in the error.
+		q.qt = append(q.qt, Operator{operator: name, value: value,
+			err: fmt.Errorf("%s must follow a history operator like WAS or CHANGED", name)})
+		return q
+	}
+
+	predicates, isHistory := historyPredicates[op.operator]
+	switch {
+	case op.err != nil:
+		// Keep the first error
+	case !isHistory:
+		op.err = fmt.Errorf("%s can only be used with history operators like WAS or CHANGED, not %s", name, op.operator)
+	case !slices.Contains(predicates, name):
+		op.err = fmt.Errorf("%s can't be used with %s, expected one of %s", name, op.operator, strings.Join(predicates, ", "))
+	case valueErr != nil:
+		op.err = valueErr
+	}
+	op.predicates = append(op.predicates, pred)
+	q.qt[len(q.qt)-1] = op
+	return q
+}
@@ -2,6 +2,7 @@ package jql
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -16,6 +17,8 @@ const (
 	unknownType wordType = iota
 	operatorType
 	keywordType
+	// prefixKeywordType is a keyword that comes before a clause, like NOT.
+	prefixKeywordType
 	endKeywordType
 )
 
@@ -24,10 +27,27 @@ type Operator struct {
 	field    string
 	operator string
 	value    string
+	// predicates follow history operators, like "BY 'alice'".
+	predicates []string
+	// err is set if the operator is invalid, and is reported by Build.
+	err error
+	// verbatim is set for fields that were unquoted in a parsed query, which
+	// are written as they were, so names like issue.property[x].y aren't
+	// quoted into a different field.
+	verbatim bool
 }
 
 func (c Operator) String() string {
-	return fmt.Sprintf("%s %s %s", c.field, c.operator, c.value)
+	parts := make([]string, 0, 3+len(c.predicates))
+	if c.field != "" {
+		parts = append(parts, writeField(c.field, c.verbatim))
+	}
+	parts = append(parts, c.operator)
+	if c.value != "" {
+		parts = append(parts, c.value)
+	}
+	parts = append(parts, c.predicates...)
+	return strings.Join(parts, " ")
 }
 
 func (c Operator) Type() wordType {
@@ -52,32 +72,72 @@ func (k Keyword) Type() wordType {
 var (
 	And            = Keyword{"AND", keywordType, false}
 	Or             = Keyword{"OR", keywordType, false}
-	Not            = Keyword{"NOT", keywordType, false}
+	Not            = Keyword{"NOT", prefixKeywordType, false}
 	OrderByKeyword = Keyword{"ORDER BY", endKeywordType, true}
 )
 
-// OrderBy represents the ORDER BY component in JQL, with fields and sorting direction
+// Group represents clauses in parentheses, used like a single operator.
+type Group struct {
+	words []jqlWord
+}
+
+func (g Group) String() string {
+	words := make([]string, len(g.words))
+	for i, w := range g.words {
+		words[i] = w.String()
+	}
+	return "(" + strings.Join(words, " ") + ")"
+}
+
+func (g Group) Type() wordType {
+	return operatorType
+}
+
+// OrderBy represents the ORDER BY component in JQL, with the fields and
+// their sorting directions.
 type OrderBy struct {
-	fields    []string
-	ascending bool
+	fields []orderField
+	// err is set if the fields are invalid, and is reported by Build.
+	err error
+}
+
+// orderField is a field in an ORDER BY clause. An empty direction uses the
+// default direction of the field.
+type orderField struct {
+	field     string
+	direction string
+	// verbatim is set for fields that were unquoted in a parsed query, like
+	// Operator.verbatim.
+	verbatim bool
 }
 
 func (o OrderBy) String() string {
-	order := "ASC"
-	if !o.ascending {
-		order = "DESC"
+	fields := make([]string, len(o.fields))
+	for i, f := range o.fields {
+		fields[i] = writeField(f.field, f.verbatim)
+		if f.direction != "" {
+			fields[i] += " " + f.direction
+		}
 	}
-	return fmt.Sprintf("ORDER BY %s %s", strings.Join(o.fields, ", "), order)
+	return "ORDER BY " + strings.Join(fields, ", ")
 }
 
 func (o OrderBy) Type() wordType {
 	return endKeywordType
 }
 
+// has reports whether the field is already ordered by, ignoring case.
+func (o OrderBy) has(field string) bool {
+	return slices.ContainsFunc(o.fields, func(f orderField) bool { return strings.EqualFold(f.field, field) })
+}
+
 // JQLQueryBuilder is the main query builder struct
 type JQLQueryBuilder struct {
-	qt             []jqlWord
-	rawQueryString string
+	qt []jqlWord
+	// order is the ORDER BY of parsed queries, moved to the end of the query.
+	order []orderField
+	// err is the first syntax error of parsed queries, reported by Build.
+	err error
 }
 
 // NewJQLQuery initializes a new JQLQuery
@@ -87,36 +147,134 @@ func NewBuilder() *JQLQueryBuilder {
 	}
 }
 
+// SetJQLString replaces the query with the parsed JQL query. Syntax errors
+// are reported by Build.
 func (q *JQLQueryBuilder) SetJQLString(jqlString string) *JQLQueryBuilder {
-	q.rawQueryString = jqlString
+	q.qt, q.order, q.err = parse(jqlString)
 	return q
 }
 
 func (q *JQLQueryBuilder) And() *JQLQueryBuilder {
-	q.qt = append(q.qt, And)
+	if !q.onlyOrdered() {
+		q.qt = append(q.qt, And)
+	}
 	return q
 }
 
 func (q *JQLQueryBuilder) Or() *JQLQueryBuilder {
-	q.qt = append(q.qt, Or)
+	if !q.onlyOrdered() {
+		q.qt = append(q.qt, Or)
+	}
+	return q
+}
+
+// onlyOrdered reports whether the query so far is only the ORDER BY of a
+// parsed query, which AND and OR have no clause to join to.
+func (q *JQLQueryBuilder) onlyOrdered() bool {
+	return len(q.qt) == 0 && len(q.order) > 0
+}
+
+// Not negates the clause or group that follows it.
+//
+// NOT status = 'Done'
+func (q *JQLQueryBuilder) Not() *JQLQueryBuilder {
+	q.qt = append(q.qt, Not)
+	return q
+}
+
+// Group adds the clauses added to the builder passed to fn in parentheses,
+// to be combined with the rest of the query as a single clause.
+// The ORDER BY of a query parsed with SetJQLString in the group is moved to
+// the end of the query. A parsed query that's only an ORDER BY has nothing to
+// group, so only its order is kept, along with the rest of the query without
+// the AND or OR joining it.
+//
+// project = 'X' AND (type = 'Bug' OR priority = 'High')
+func (q *JQLQueryBuilder) Group(fn func(g *JQLQueryBuilder)) *JQLQueryBuilder {
+	g := NewBuilder()
+	fn(g)
+	if g.onlyOrdered() {
+		if n := len(q.qt); n > 0 && (q.qt[n-1] == And || q.qt[n-1] == Or) {
+			q.qt = q.qt[:n-1]
+		}
+	} else {
+		q.qt = append(q.qt, Group{words: g.qt})
+	}
+	q.order = append(q.order, g.order...)
+	if q.err == nil {
+		q.err = g.err
+	}
 	return q
 }
 
-// OrderBy adds an ORDER BY clause with specified fields and sorting direction
+// GroupJQL adds the parsed JQL query in parentheses, so a query from a user
+// can safely be combined with other clauses. Its ORDER BY, if any, is moved
+// to the end of the query.
+//
+// (assignee = currentUser() OR reporter = currentUser()) AND project = 'X'
+func (q *JQLQueryBuilder) GroupJQL(jqlString string) *JQLQueryBuilder {
+	return q.Group(func(g *JQLQueryBuilder) { g.SetJQLString(jqlString) })
+}
+
+// OrderBy orders the results by the fields in the same direction. It can be
+// called again, or combined with OrderByAsc and OrderByDesc, to order by more
+// fields in other directions, which are added to the same ORDER BY clause.
+//
+// ORDER BY priority DESC, created ASC
 func (q *JQLQueryBuilder) OrderBy(ascending bool, fields ...string) *JQLQueryBuilder {
-	q.qt = append(q.qt, OrderBy{fields: fields, ascending: ascending})
+	direction := "ASC"
+	if !ascending {
+		direction = "DESC"
+	}
+	return q.orderBy(direction, fields)
+}
+
+// OrderByAsc orders the results by the fields in ascending order.
+func (q *JQLQueryBuilder) OrderByAsc(fields ...string) *JQLQueryBuilder {
+	return q.orderBy("ASC", fields)
+}
+
+// OrderByDesc orders the results by the fields in descending order.
+func (q *JQLQueryBuilder) OrderByDesc(fields ...string) *JQLQueryBuilder {
+	return q.orderBy("DESC", fields)
+}
+
+// orderBy adds the fields to the ORDER BY clause if it's the last word, or
+// starts a new one. A field can only be ordered by once.
+func (q *JQLQueryBuilder) orderBy(direction string, fields []string) *JQLQueryBuilder {
+	var o OrderBy
+	merge := false
+	if n := len(q.qt); n > 0 {
+		o, merge = q.qt[n-1].(OrderBy)
+	}
+
+	if len(fields) == 0 && o.err == nil {
+		o.err = fmt.Errorf("%s requires at least one field", OrderByKeyword.String())
+	}
+	for _, f := range fields {
+		if o.err == nil && o.has(f) {
+			o.err = fmt.Errorf("field %q is already in %s", f, OrderByKeyword.String())
+		}
+		o.fields = append(o.fields, orderField{field: f, direction: direction})
+	}
+
+	if merge {
+		q.qt[len(q.qt)-1] = o
+	} else {
+		q.qt = append(q.qt, o)
+	}
 	return q
 }
 
 // Equals adds an equality operator for a field
 func (q *JQLQueryBuilder) Equals(field string, value string) *JQLQueryBuilder {
-	q.qt = append(q.qt, Operator{field: field, operator: "=", value: fmt.Sprintf("'%s'", value)})
+	q.qt = append(q.qt, Operator{field: field, operator: "=", value: quoteValue(value)})
 	return q
 }
 
 // NotEquals adds an inequality operator for a field
 func (q *JQLQueryBuilder) NotEquals(field string, value string) *JQLQueryBuilder {
-	q.qt = append(q.qt, Operator{field: field, operator: "!=", value: fmt.Sprintf("'%s'", value)})
+	q.qt = append(q.qt, Operator{field: field, operator: "!=", value: quoteValue(value)})
 	return q
 }
 
@@ -124,20 +282,94 @@ func (q *JQLQueryBuilder) NotEquals(field string, value string) *JQLQueryBuilder
 //
 // field IN ('value1', 'value2', ...)
 func (q *JQLQueryBuilder) In(field string, values ...string) *JQLQueryBuilder {
-	valueList := "('" + strings.Join(values, "', '") + "')"
-	q.qt = append(q.qt, Operator{field: field, operator: "IN", value: valueList})
+	q.qt = append(q.qt, listOperator(field, "IN", values))
 	return q
 }
 
 // NotIn adds a NOT IN operator for a field with a list of values
 func (q *JQLQueryBuilder) NotIn(field string, values []string) *JQLQueryBuilder {
-	valueList := "('" + strings.Join(values, "', '") + "')"
-	q.qt = append(q.qt, Operator{field: field, operator: "NOT IN", value: valueList})
+	q.qt = append(q.qt, listOperator(field, "NOT IN", values))
+	return q
+}
+
+// listOperator returns an operator with a list of values, like IN.
+func listOperator(field string, operator string, values []string) Operator {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteValue(v)
+	}
+	op := Operator{field: field, operator: operator, value: "(" + strings.Join(quoted, ", ") + ")"}
+	if len(values) == 0 {
+		op.err = fmt.Errorf("%s requires at least one value", operator)
+	}
+	return op
+}
+
+// GreaterThan adds a > operator for a field, usually a date or a number.
+func (q *JQLQueryBuilder) GreaterThan(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: ">", value: quoteValue(value)})
 	return q
 }
 
+// GreaterThanOrEquals adds a >= operator for a field, usually a date.
+// Relative dates such as "-7d" are valid values for date fields.
+func (q *JQLQueryBuilder) GreaterThanOrEquals(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: ">=", value: quoteValue(value)})
+	return q
+}
+
+// LessThan adds a < operator for a field, usually a date or a number.
+func (q *JQLQueryBuilder) LessThan(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "<", value: quoteValue(value)})
+	return q
+}
+
+// LessThanOrEquals adds a <= operator for a field, usually a date or a number.
+func (q *JQLQueryBuilder) LessThanOrEquals(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "<=", value: quoteValue(value)})
+	return q
+}
+
+// Contains adds a ~ operator for a text search on a field.
 func (q *JQLQueryBuilder) Contains(field string, value string) *JQLQueryBuilder {
-	q.qt = append(q.qt, Operator{field: field, operator: "~", value: fmt.Sprintf("'%s'", value)})
+	q.qt = append(q.qt, Operator{field: field, operator: "~", value: quoteValue(value)})
+	return q
+}
+
+// NotContains adds a !~ operator, matching issues where the text search on
+// the field doesn't match.
+func (q *JQLQueryBuilder) NotContains(field string, value string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "!~", value: quoteValue(value)})
+	return q
+}
+
+// IsEmpty matches issues where the field isn't set.
+//
+// assignee IS EMPTY
+func (q *JQLQueryBuilder) IsEmpty(field string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "IS", value: "EMPTY"})
+	return q
+}
+
+// IsNotEmpty matches issues where the field is set.
+//
+// assignee IS NOT EMPTY
+func (q *JQLQueryBuilder) IsNotEmpty(field string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "IS NOT", value: "EMPTY"})
+	return q
+}
+
+// IsNull matches issues where the field isn't set, same as IsEmpty.
+//
+// fixVersion IS NULL
+func (q *JQLQueryBuilder) IsNull(field string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "IS", value: "NULL"})
+	return q
+}
+
+// IsNotNull matches issues where the field is set, same as IsNotEmpty.
+func (q *JQLQueryBuilder) IsNotNull(field string) *JQLQueryBuilder {
+	q.qt = append(q.qt, Operator{field: field, operator: "IS NOT", value: "NULL"})
 	return q
 }
 
@@ -145,68 +377,130 @@ func (q *JQLQueryBuilder) Contains(field string, value string) *JQLQueryBuilder
 // It returns an error if the query is invalid.
 // Only syntactic validation is done, stopping at the first detected error while still building the full query.
 func (q *JQLQueryBuilder) Build() (string, error) {
-	if len(q.qt) == 0 && q.rawQueryString == "" {
+	// Syntax errors of parsed queries already point at the error
+	if q.err != nil {
+		return "", q.err
+	}
+
+	words := q.words()
+	if len(words) == 0 {
 		return "", fmt.Errorf("no query parts added")
 	}
 
-	// If a raw query string was set, return it directly
-	if q.rawQueryString != "" {
-		return q.rawQueryString, nil
+	// A parsed query can be only an ORDER BY, which is valid JQL
+	if len(q.qt) == 0 {
+		return words[0].String(), nil
 	}
 
 	var builder strings.Builder
+	errCharPos, err := writeWords(&builder, words, false)
+	finalQuery := builder.String()
+
+	// If error was captured, return it with the full query and a pointer to the error position
+	if err != nil {
+		return "", fmt.Errorf("invalid query:\n%s\nError: %s", pointer(finalQuery, errCharPos), err.Error())
+	}
+
+	return finalQuery, nil
+}
+
+// Ordered reports whether the query has an ORDER BY clause, added with the
+// builder or in parsed JQL.
+func (q *JQLQueryBuilder) Ordered() bool {
+	words := q.words()
+	if n := len(words); n > 0 {
+		_, ok := words[n-1].(OrderBy)
+		return ok
+	}
+	return false
+}
+
+// words returns the words of the query, with the ORDER BY of parsed queries
+// added to the ORDER BY at the end. Fields that are already ordered by are
+// skipped.
+func (q *JQLQueryBuilder) words() []jqlWord {
+	if len(q.order) == 0 {
+		return q.qt
+	}
+	words := slices.Clone(q.qt)
+	var o OrderBy
+	if n := len(words); n > 0 {
+		if last, ok := words[n-1].(OrderBy); ok {
+			o = last
+			words = words[:n-1]
+		}
+	}
+	o.fields = slices.Clone(o.fields)
+	for _, f := range q.order {
+		if !o.has(f.field) {
+			o.fields = append(o.fields, f)
+		}
+	}
+	return append(words, o)
+}
+
+// writeWords writes the words to the builder, separated by spaces, and
+// validates them, recursing into groups. nested is set for the words of a group.
+// Only syntactic validation is done, stopping at the first detected error while still writing all words.
+// It returns the position of the error in the builder and the error.
+func writeWords(builder *strings.Builder, words []jqlWord, nested bool) (int, error) {
 	var lastWord jqlWord
 	var err error
 	var errCharPos int
-	currentPosition := 0
+	start := builder.Len()
 
-	for i, word := range q.qt {
-		wordStr := word.String()
+	for i, word := range words {
+		// Append a space between words
+		if builder.Len() > start {
+			builder.WriteString(" ")
+		}
 
 		if err == nil {
 			// Validate the current word if we haven't encountered an error yet
 			// If we have, we skip validation to avoid duplicate error messages
 			err = validateWord(i, word, lastWord)
-			errCharPos = currentPosition
+			if err == nil && nested && word.Type() == endKeywordType {
+				err = fmt.Errorf("%q cannot be used in a group", OrderByKeyword.String())
+			}
+			errCharPos = builder.Len()
 		}
 
-		// Append word to builder and update the current character position
-		if builder.Len() > 0 {
-			builder.WriteString(" ")
-			currentPosition++ // Account for added space between words
+		if g, ok := word.(Group); ok {
+			// Write the group word by word to find errors inside it
+			builder.WriteString("(")
+			groupErrPos, groupErr := writeWords(builder, g.words, true)
+			if err == nil && groupErr != nil {
+				err, errCharPos = groupErr, groupErrPos
+			}
+			if err == nil && len(g.words) == 0 {
+				err = fmt.Errorf("group cannot be empty")
+			}
+			builder.WriteString(")")
+		} else {
+			builder.WriteString(word.String())
 		}
-		builder.WriteString(wordStr)
-		currentPosition += len(wordStr) // Account for word length
 		lastWord = word
 	}
 
-	finalQuery := builder.String()
-
-	// If we haven't encountered an error yet, validate the final query
-	if err == nil {
+	// If we haven't encountered an error yet, validate the end of the query or group
+	if err == nil && lastWord != nil {
 		// Final validation: Ensure the query does not end with a keyword
 		// Example of invalid query: "status = 'Open' AND"
-		if lastWord.Type() == keywordType {
+		if lastWord.Type() == keywordType || lastWord.Type() == prefixKeywordType {
 			err = fmt.Errorf("query cannot end with a keyword")
-			errCharPos = currentPosition
+			errCharPos = builder.Len()
 		}
 	}
 
-	// If error was captured, return it with the full query and a pointer to the error position
-	if err != nil {
-		pointerLine := strings.Repeat(" ", errCharPos) + "^"
-		return "", fmt.Errorf("invalid query:\n%q\n%s\nError: %s", finalQuery, pointerLine, err.Error())
-	}
-
-	return finalQuery, nil
+	return errCharPos, err
 }
 
 func validateWord(i int, word jqlWord, lastWord jqlWord) error {
 	// Validate the first word
-	// The first word of the query must be an operator (e.g., "status = 'Open'").
-	// If the first word is an operator, keyword, or end keyword, it's invalid.
-	if i == 0 && word.Type() != operatorType {
-		return fmt.Errorf("first word must be an operator, got %q", word.String())
+	// The first word of the query must be an operator (e.g., "status = 'Open'"), a group or NOT.
+	// If the first word is a keyword or end keyword, it's invalid.
+	if i == 0 && word.Type() != operatorType && word.Type() != prefixKeywordType {
+		return fmt.Errorf("first word must be an operator or NOT, got %q", word.String())
 	}
 
 	if lastWord == nil {
@@ -223,9 +517,15 @@ func validateWord(i int, word jqlWord, lastWord jqlWord) error {
 			return fmt.Errorf("consecutive operators %q & %q", lastWord.String(), word.String())
 		}
 	case keywordType:
-		if lastWord.Type() == keywordType {
+		if lastWord.Type() == keywordType || lastWord.Type() == prefixKeywordType {
 			return fmt.Errorf("consecutive keywords %q & %q", lastWord.String(), word.String())
 		}
+	case prefixKeywordType:
+		// NOT starts a clause, so it can't directly follow another one.
+		// Example of invalid query: "status = 'Open' NOT type = 'Bug'"
+		if lastWord.Type() == operatorType {
+			return fmt.Errorf("missing AND or OR between %q & %q", lastWord.String(), word.String())
+		}
 	case endKeywordType:
 		// Ensure end keywords appear at the end of the query
 		// End keywords (e.g., "ORDER BY") should only appear at the end.
@@ -240,5 +540,13 @@ func validateWord(i int, word jqlWord, lastWord jqlWord) error {
 		return fmt.Errorf("keyword %q must be the last part of the query", lastWord.String())
 	}
 
+	// Check the operator or ORDER BY itself once it's known to be in the right place
+	switch w := word.(type) {
+	case Operator:
+		return w.err
+	case OrderBy:
+		return w.err
+	}
+
 	return nil
 }
@@ -24,6 +24,346 @@ func TestValidSimpleQuery(t *testing.T) {
 	}
 }
 
+func TestGreaterThanOrEquals(t *testing.T) {
+	s, err := NewBuilder().Equals("project", "ABC").And().GreaterThanOrEquals("updated", "-7d").Build()
+	if err != nil {
+		t.Fatalf("failed to build query: %s", err)
+	}
+
+	expected := `project = 'ABC' AND updated >= '-7d'`
+	if s != expected {
+		t.Fatalf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	testData := []struct {
+		name     string
+		fn       func(*JQLQueryBuilder) *JQLQueryBuilder
+		expected string
+	}{
+		{
+			name:     "apostrophe in value",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Contains("summary", "don't*") },
+			expected: `summary ~ 'don\'t*'`,
+		},
+		{
+			name:     "apostrophe in list",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.In("component", "O'Brien Team", "Backend") },
+			expected: `component IN ('O\'Brien Team', 'Backend')`,
+		},
+		{
+			// The value can't close the string and add its own clauses.
+			name:     "injection",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("summary", "x' OR project = 'SECRET") },
+			expected: `summary = 'x\' OR project = \'SECRET'`,
+		},
+		{
+			// A trailing backslash can't escape the closing quote.
+			name:     "backslashes",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("summary", `C:\temp\`) },
+			expected: `summary = 'C:\\temp\\'`,
+		},
+		{
+			name:     "newline",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("summary", "a\nb") },
+			expected: `summary = 'a\nb'`,
+		},
+		{
+			name:     "field with spaces",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("Story Points", "3") },
+			expected: `"Story Points" = '3'`,
+		},
+		{
+			name:     "custom field reference",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("cf[10010]", "3") },
+			expected: `cf[10010] = '3'`,
+		},
+		{
+			name:     "reserved word field",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals("Order", "1").OrderBy(true, "Start") },
+			expected: `"Order" = '1' ORDER BY "Start" ASC`,
+		},
+		{
+			name:     "quote in field",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.Equals(`x" = 1 OR "y`, "1") },
+			expected: `"x\" = 1 OR \"y" = '1'`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.fn(NewBuilder()).Build()
+			if err != nil {
+				t.Fatalf("failed to build query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	qb := NewBuilder()
+	qb.Equals("project", "X").
+		And().
+		Group(func(g *JQLQueryBuilder) {
+			g.Equals("type", "Bug").
+				Or().
+				Not().
+				Group(func(g *JQLQueryBuilder) {
+					g.Equals("priority", "Low").Or().Equals("priority", "Lowest")
+				})
+		}).
+		OrderBy(false, "created")
+
+	s, err := qb.Build()
+	if err != nil {
+		t.Fatalf("failed to build query: %s", err)
+	}
+
+	expected := `project = 'X' AND (type = 'Bug' OR NOT (priority = 'Low' OR priority = 'Lowest')) ORDER BY created DESC`
+	if s != expected {
+		t.Fatalf("expected %q, got %q", expected, s)
+	}
+
+	s, err = NewBuilder().Not().Equals("status", "Done").And().Group(func(g *JQLQueryBuilder) {
+		g.SetJQLString("assignee = currentUser() OR reporter = currentUser()")
+	}).Build()
+	if err != nil {
+		t.Fatalf("failed to build query: %s", err)
+	}
+
+	expected = `NOT status = 'Done' AND (assignee = currentUser() OR reporter = currentUser())`
+	if s != expected {
+		t.Fatalf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	testData := []struct {
+		name     string
+		fn       func(*JQLQueryBuilder) *JQLQueryBuilder
+		expected string
+	}{
+		{
+			name: "comparisons",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.GreaterThan("votes", "3").And().LessThanOrEquals("created", "2024-10-01").And().LessThan("updated", "-1d")
+			},
+			expected: `votes > '3' AND created <= '2024-10-01' AND updated < '-1d'`,
+		},
+		{
+			name:     "not contains",
+			fn:       func(q *JQLQueryBuilder) *JQLQueryBuilder { return q.NotContains("summary", "flaky") },
+			expected: `summary !~ 'flaky'`,
+		},
+		{
+			name: "empty",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.IsEmpty("assignee").Or().IsNotEmpty("Story Points").And().IsNull("fixVersion")
+			},
+			expected: `assignee IS EMPTY OR "Story Points" IS NOT EMPTY AND fixVersion IS NULL`,
+		},
+		{
+			name: "was",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Was("status", "Open").By(String("alice")).Before(String("2024-10-01"))
+			},
+			expected: `status WAS 'Open' BY 'alice' BEFORE '2024-10-01'`,
+		},
+		{
+			name: "was in",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.WasIn("status", "Open", "Reopened").During(String("2024-01-01"), StartOfMonth())
+			},
+			expected: `status WAS IN ('Open', 'Reopened') DURING ('2024-01-01', startOfMonth())`,
+		},
+		{
+			name: "was not",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.WasNot("status", "Done").And().WasNotIn("priority", "Low").On(String("2024-10-01"))
+			},
+			expected: `status WAS NOT 'Done' AND priority WAS NOT IN ('Low') ON '2024-10-01'`,
+		},
+		{
+			name: "changed",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Changed("status").From(String("Open")).To(String("Done")).After(RelativeDate("-7d"))
+			},
+			expected: `status CHANGED FROM 'Open' TO 'Done' AFTER -7d`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.fn(NewBuilder()).Build()
+			if err != nil {
+				t.Fatalf("failed to build query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	testData := []struct {
+		name     string
+		fn       func(*JQLQueryBuilder) *JQLQueryBuilder
+		expected string
+	}{
+		{
+			name: "current user",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("assignee", "=", CurrentUser())
+			},
+			expected: "assignee = currentUser()",
+		},
+		{
+			name: "sprints",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("sprint", "in", OpenSprints(), FutureSprints())
+			},
+			expected: "sprint IN (openSprints(), futureSprints())",
+		},
+		{
+			name: "relative date",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("updated", ">=", RelativeDate("-7d")).And().Cond("created", "<", StartOfWeek("-1w"))
+			},
+			expected: "updated >= -7d AND created < startOfWeek(-1w)",
+		},
+		{
+			name: "function arguments",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("issue", "IN", LinkedIssues("PRJ-1", "is blocked by")).
+					Or().Cond("assignee", "IN", MembersOf("jira-users"))
+			},
+			expected: `issue IN (linkedIssues(PRJ-1, "is blocked by")) OR assignee IN (membersOf(jira-users))`,
+		},
+		{
+			name: "mixed values",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("assignee", "NOT IN", String("alice"), CurrentUser()).And().Cond("fixVersion", "IS", Empty)
+			},
+			expected: "assignee NOT IN ('alice', currentUser()) AND fixVersion IS EMPTY",
+		},
+		{
+			name: "app function",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("issue", "in", Func("subtasksOf", "PRJ-1"))
+			},
+			expected: "issue IN (subtasksOf(PRJ-1))",
+		},
+		{
+			name: "was by current user",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("status", "was", String("Done")).By(CurrentUser()).After(StartOfMonth())
+			},
+			expected: "status WAS 'Done' BY currentUser() AFTER startOfMonth()",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.fn(NewBuilder()).Build()
+			if err != nil {
+				t.Fatalf("failed to build query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	testData := []struct {
+		name  string
+		fn    func(*JQLQueryBuilder) *JQLQueryBuilder
+		caret string
+	}{
+		{
+			name: "top level",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").Equals("priority", "High")
+			},
+			caret: strings.Repeat(" ", 17) + "^",
+		},
+		{
+			// The caret points at the second operator in the group, counting the
+			// opening quote of the printed query.
+			name: "in group",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("p", "1").And().Group(func(g *JQLQueryBuilder) {
+					g.Equals("q", "2").Equals("r", "3")
+				})
+			},
+			caret: strings.Repeat(" ", 22) + "^",
+		},
+		{
+			name: "group ending with keyword",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Group(func(g *JQLQueryBuilder) {
+					g.Equals("b", "2").Or()
+				})
+			},
+			caret: strings.Repeat(" ", 12) + "^",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.fn(NewBuilder()).Build()
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) < 3 || lines[2] != tt.caret {
+				t.Fatalf("expected caret %q, got %q", tt.caret, err.Error())
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	testData := []struct {
+		name     string
+		fn       func(*JQLQueryBuilder) *JQLQueryBuilder
+		expected string
+	}{
+		{
+			name: "per field direction",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").OrderByDesc("priority").OrderByAsc("created")
+			},
+			expected: "status = 'Open' ORDER BY priority DESC, created ASC",
+		},
+		{
+			name: "repeated OrderBy",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").OrderBy(true, "created").OrderBy(false, "updated", "Story Points")
+			},
+			expected: `status = 'Open' ORDER BY created ASC, updated DESC, "Story Points" DESC`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.fn(NewBuilder()).Build()
+			if err != nil {
+				t.Fatalf("failed to build query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
 func TestValidComplexQuery(t *testing.T) {
 	builder := NewBuilder()
 
@@ -65,7 +405,7 @@ func TestValidComplexQuery(t *testing.T) {
 		t.Fatalf("failed to build query: %s", err)
 	}
 
-	expQuery := `status = 'Open' AND priority != 'Low' AND assignee IN ('Alice', 'Bob', 'Charlie') AND project NOT IN ('ProjectA', 'ProjectB') OR reporter = 'Dave' AND issueType != 'Bug' OR labels IN ('critical', 'urgent') AND resolution = 'Unresolved' AND created = '2023-10-01' AND updated != '2023-11-01' AND component IN ('Backend', 'Frontend') ORDER BY priority, created ASC`
+	expQuery := `status = 'Open' AND priority != 'Low' AND assignee IN ('Alice', 'Bob', 'Charlie') AND project NOT IN ('ProjectA', 'ProjectB') OR reporter = 'Dave' AND issueType != 'Bug' OR labels IN ('critical', 'urgent') AND resolution = 'Unresolved' AND created = '2023-10-01' AND updated != '2023-11-01' AND component IN ('Backend', 'Frontend') ORDER BY priority ASC, created ASC`
 
 	if query != expQuery {
 		t.Fatalf("expected %q, got %q", expQuery, query)
@@ -94,13 +434,32 @@ func TestInvalidQueries(t *testing.T) {
 			errMsg: "consecutive keywords",
 		},
 		{
-			// Invalid because "ORDER BY" should not follow another "ORDER BY" directly
-			// Query: "ORDER BY created ASC ORDER BY updated DESC"
-			name: "consecutive ORDER BY",
+			// Invalid because a field can only be ordered by once
+			// Query: "status = 'Open' ORDER BY created ASC, created DESC"
+			name: "field ordered twice",
 			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
-				return q.Equals("status", "Open").OrderBy(true, "created").OrderBy(false, "updated")
+				return q.Equals("status", "Open").OrderBy(true, "created").OrderByDesc("Created")
 			},
-			errMsg: "consecutive keywords",
+			errMsg: `field "Created" is already in ORDER BY`,
+		},
+		{
+			// Invalid because ORDER BY needs a field
+			// Query: "status = 'Open' ORDER BY"
+			name: "ORDER BY without fields",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").OrderByAsc()
+			},
+			errMsg: "ORDER BY requires at least one field",
+		},
+		{
+			// Invalid because ORDER BY clauses are only merged if they're next
+			// to each other
+			// Query: "status = 'Open' ORDER BY created ASC AND priority = 'High' ORDER BY updated DESC"
+			name: "ORDER BY in the middle",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").OrderBy(true, "created").And().Equals("priority", "High").OrderByDesc("updated")
+			},
+			errMsg: "must be the last part of the query",
 		},
 		{
 			// Invalid because an operator cannot directly follow a keyword like "OR"
@@ -173,6 +532,158 @@ func TestInvalidQueries(t *testing.T) {
 				return q.Equals("status", "Open").And().And().Equals("priority", "High")
 			},
 		},
+		{
+			// Invalid because NOT must be joined to the clause before it
+			// Query: "status = 'Open' NOT type = 'Bug'"
+			name: "NOT after operator",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").Not().Equals("type", "Bug")
+			},
+			errMsg: "missing AND or OR",
+		},
+		{
+			// Invalid because a query cannot end with NOT
+			// Query: "status = 'Open' AND NOT"
+			name: "end with NOT",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").And().Not()
+			},
+			errMsg: "query cannot end with a keyword",
+		},
+		{
+			// Invalid because groups can't be empty
+			// Query: "status = 'Open' AND ()"
+			name: "empty group",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").And().Group(func(*JQLQueryBuilder) {})
+			},
+			errMsg: "group cannot be empty",
+		},
+		{
+			// Invalid because a group is a clause and needs a keyword before it
+			// Query: "status = 'Open' (type = 'Bug')"
+			name: "group after operator",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").Group(func(g *JQLQueryBuilder) { g.Equals("type", "Bug") })
+			},
+			errMsg: "consecutive operators",
+		},
+		{
+			// Invalid because ORDER BY applies to the whole query
+			// Query: "(status = 'Open' ORDER BY created ASC)"
+			name: "ORDER BY in group",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Group(func(g *JQLQueryBuilder) { g.Equals("status", "Open").OrderBy(true, "created") })
+			},
+			errMsg: "cannot be used in a group",
+		},
+		{
+			// Invalid because predicates only narrow down history operators
+			// Query: "status = 'Open' BY 'alice'"
+			name: "predicate after equals",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Equals("status", "Open").By(String("alice"))
+			},
+			errMsg: "BY can only be used with history operators",
+		},
+		{
+			// Invalid because FROM and TO are only supported by CHANGED
+			// Query: "status WAS 'Open' FROM 'Done'"
+			name: "FROM with WAS",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Was("status", "Open").From(String("Done"))
+			},
+			errMsg: "FROM can't be used with WAS",
+		},
+		{
+			// Invalid because a predicate needs an operator before it
+			// Query: "status CHANGED AND BY 'alice'"
+			name: "predicate after keyword",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Changed("status").And().By(String("alice"))
+			},
+			errMsg: "BY must follow a history operator",
+		},
+		{
+			// Invalid because WAS IN needs at least one value
+			// Query: "status WAS IN ()"
+			name: "empty WAS IN",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.WasIn("status")
+			},
+			errMsg: "WAS IN requires at least one value",
+		},
+		{
+			// Invalid because IN needs at least one value
+			// Query: "status IN ()"
+			name: "empty IN",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.In("status")
+			},
+			errMsg: "IN requires at least one value",
+		},
+		{
+			// Invalid because currentUser() takes no arguments
+			// Query: "assignee = currentUser(alice)"
+			name: "function with too many arguments",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("assignee", "=", Func("currentUser", "alice"))
+			},
+			errMsg: "currentUser() takes 0 arguments, got 1",
+		},
+		{
+			// Invalid because membersOf() needs a group
+			// Query: "assignee IN (membersOf())"
+			name: "function with too few arguments",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("assignee", "IN", Func("membersOf"))
+			},
+			errMsg: "membersOf() takes 1 argument, got 0",
+		},
+		{
+			// Invalid because relative dates need a unit
+			// Query: "updated >= -7"
+			name: "relative date without unit",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("updated", ">=", RelativeDate("-7"))
+			},
+			errMsg: `invalid relative date "-7"`,
+		},
+		{
+			// Invalid because = takes a single value
+			// Query: "status = 'Open', 'Done'"
+			name: "too many values",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("status", "=", String("Open"), String("Done"))
+			},
+			errMsg: "= takes 1 value, got 2",
+		},
+		{
+			// Invalid because IS only takes EMPTY or NULL
+			// Query: "assignee IS currentUser()"
+			name: "IS with function",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("assignee", "IS", CurrentUser())
+			},
+			errMsg: "IS only takes EMPTY or NULL",
+		},
+		{
+			// Invalid because LIKE isn't a JQL operator
+			name: "unknown operator",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Cond("summary", "LIKE", String("foo"))
+			},
+			errMsg: `unknown operator "LIKE"`,
+		},
+		{
+			// Invalid because the predicate value is checked too
+			// Query: "status CHANGED AFTER 7"
+			name: "invalid predicate value",
+			fn: func(q *JQLQueryBuilder) *JQLQueryBuilder {
+				return q.Changed("status").After(RelativeDate("7"))
+			},
+			errMsg: `invalid relative date "7"`,
+		},
 		{
 			// No query parts
 			name:   "empty query",
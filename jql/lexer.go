@@ -0,0 +1,168 @@
+package jql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind is the kind of a token in a JQL query.
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	// wordToken is an unquoted word, like status, AND, PRJ-1 or -7d.
+	wordToken
+	// stringToken is a quoted string, with the quotes and escaping removed.
+	stringToken
+	// operatorToken is a comparison operator, like = or !~.
+	operatorToken
+	lparenToken
+	rparenToken
+	commaToken
+)
+
+// token is a token in a JQL query.
+type token struct {
+	kind tokenKind
+	text string
+	// pos is the byte offset of the token in the query.
+	pos int
+}
+
+func (t token) String() string {
+	if t.kind == eofToken {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// syntaxError is an error at a position in a query.
+type syntaxError struct {
+	pos int
+	msg string
+}
+
+func (e *syntaxError) Error() string {
+	return e.msg
+}
+
+// delimiters end an unquoted word, apart from the !, & and | that wordLength
+// keeps in it.
+const delimiters = " \t\r\n\"'(),=!~<>&|"
+
+// lex splits the query into tokens, ending with an eofToken.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	add := func(kind tokenKind, text string, pos int, n int) int {
+		tokens = append(tokens, token{kind: kind, text: text, pos: pos})
+		return pos + n
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		next := byte(0)
+		if i+1 < len(query) {
+			next = query[i+1]
+		}
+
+		switch {
+		case strings.IndexByte(" \t\r\n", c) >= 0:
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := unquote(query[i:])
+			if err != nil {
+				return nil, &syntaxError{pos: i, msg: err.Error()}
+			}
+			i = add(stringToken, text, i, n)
+		case c == '(':
+			i = add(lparenToken, "(", i, 1)
+		case c == ')':
+			i = add(rparenToken, ")", i, 1)
+		case c == ',':
+			i = add(commaToken, ",", i, 1)
+		case c == '!' && (next == '=' || next == '~'), (c == '<' || c == '>') && next == '=':
+			i = add(operatorToken, query[i:i+2], i, 2)
+		case c == '=' || c == '~' || c == '<' || c == '>':
+			i = add(operatorToken, query[i:i+1], i, 1)
+		// JQL also has &&, || and ! for AND, OR and NOT.
+		case c == '&' && next == '&':
+			i = add(wordToken, And.String(), i, 2)
+		case c == '|' && next == '|':
+			i = add(wordToken, Or.String(), i, 2)
+		case c == '!':
+			i = add(wordToken, Not.String(), i, 1)
+		case c == '&' || c == '|':
+			return nil, &syntaxError{pos: i, msg: fmt.Sprintf("unexpected %q, did you mean %q?", c, []byte{c, c})}
+		default:
+			n := wordLength(query[i:])
+			i = add(wordToken, query[i:i+n], i, n)
+		}
+	}
+	return append(tokens, token{kind: eofToken, pos: len(query)}), nil
+}
+
+// wordLength returns the length of the unquoted word at the start of s.
+// !, & and | only end a word when they start an operator, so values like
+// foo!bar are a single word.
+func wordLength(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		next := byte(0)
+		if i+1 < len(s) {
+			next = s[i+1]
+		}
+		switch {
+		case c == '!':
+			if next == '=' || next == '~' {
+				return i
+			}
+		case c == '&' || c == '|':
+			if next == c {
+				return i
+			}
+		case strings.IndexByte(delimiters, c) >= 0:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// unquote returns the string at the start of s without its quotes and
+// escaping, and the length of the quoted string.
+func unquote(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+5 > len(s) {
+					return "", 0, fmt.Errorf("invalid unicode escape in string")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid unicode escape %q in string", s[i-1:i+5])
+				}
+				b.WriteRune(rune(r))
+				i += 4
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string, missing closing %c", quote)
+}
@@ -0,0 +1,118 @@
+package jql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FieldInfo describes a field of the JIRA instance, for Lint to check the
+// fields of a query against.
+type FieldInfo struct {
+	// Names are the names the field can be used with in JQL, like
+	// "Story Points" and "cf[10016]".
+	Names []string
+	// Text is set if the field can be searched with ~ and !~.
+	Text bool
+}
+
+// textPseudoFields search all text fields, and aren't returned by JIRA as
+// fields of their own.
+var textPseudoFields = FieldInfo{Names: []string{"text", "textfields"}, Text: true}
+
+// scopeFields limit a query to some projects or issues.
+var scopeFields = []string{"project", "issue", "issuekey", "key", "parent"}
+
+// Lint returns warnings about likely mistakes in the query: unknown fields,
+// text searches on fields that aren't text, and queries that aren't limited
+// to a project. Fields are only checked if fields isn't nil.
+// Invalid queries return the error from Build instead.
+func (q *JQLQueryBuilder) Lint(fields []FieldInfo) ([]string, error) {
+	if _, err := q.Build(); err != nil {
+		return nil, err
+	}
+
+	var known map[string]FieldInfo
+	if fields != nil {
+		known = make(map[string]FieldInfo)
+		for _, f := range slices.Concat(fields, []FieldInfo{textPseudoFields}) {
+			for _, name := range f.Names {
+				known[strings.ToLower(name)] = f
+			}
+		}
+	}
+
+	var warnings []string
+	warn := func(format string, args ...any) {
+		if w := fmt.Sprintf(format, args...); !slices.Contains(warnings, w) {
+			warnings = append(warnings, w)
+		}
+	}
+
+	var lintWords func(words []jqlWord)
+	lintWords = func(words []jqlWord) {
+		for _, w := range words {
+			switch w := w.(type) {
+			case Group:
+				lintWords(w.words)
+			case Operator:
+				if known == nil {
+					continue
+				}
+				f, ok := known[strings.ToLower(w.field)]
+				switch {
+				case !ok:
+					warn("unknown field %q", w.field)
+				case (w.operator == "~" || w.operator == "!~") && !f.Text:
+					warn("%s on %q, which isn't a text field, use = or IN instead", w.operator, w.field)
+				}
+			case OrderBy:
+				if known == nil {
+					continue
+				}
+				for _, f := range w.fields {
+					if _, ok := known[strings.ToLower(f.field)]; !ok {
+						warn("unknown field %q in %s", f.field, OrderByKeyword.String())
+					}
+				}
+			}
+		}
+	}
+	lintWords(q.words())
+
+	if !limited(q.qt) {
+		warn("query isn't limited to a project, add a project clause with AND so it doesn't search every project")
+	}
+	return warnings, nil
+}
+
+// limited reports whether the words only match issues in some projects or
+// specific issues. Clauses joined with AND are limited if one of them is,
+// and clauses joined with OR if all of them are.
+func limited(words []jqlWord) bool {
+	branch := false
+	for i, w := range words {
+		switch w := w.(type) {
+		case Keyword:
+			if w == Or {
+				if !branch {
+					return false
+				}
+				branch = false
+			}
+		case Operator:
+			// A negated clause matches everything else
+			if i > 0 && words[i-1] == Not {
+				continue
+			}
+			if slices.Contains(scopeFields, strings.ToLower(w.field)) && (w.operator == "=" || w.operator == "IN") {
+				branch = true
+			}
+		case Group:
+			if (i == 0 || words[i-1] != Not) && limited(w.words) {
+				branch = true
+			}
+		}
+	}
+	return branch
+}
@@ -0,0 +1,98 @@
+package jql
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	fields := []FieldInfo{
+		{Names: []string{"project"}},
+		{Names: []string{"status"}},
+		{Names: []string{"summary"}, Text: true},
+		{Names: []string{"labels"}},
+		{Names: []string{"created"}},
+		{Names: []string{"Story Points", "cf[10016]"}},
+	}
+
+	testData := []struct {
+		name     string
+		query    string
+		warnings []string
+	}{
+		{
+			name:  "no warnings",
+			query: `project = ABC AND (summary ~ "crash" OR text ~ "crash") AND "story points" > 3 ORDER BY created`,
+		},
+		{
+			name:  "unknown fields",
+			query: `project = ABC AND stauts = Open AND cf[10016] > 3 ORDER BY craeted`,
+			warnings: []string{
+				`unknown field "stauts"`,
+				`unknown field "craeted" in ORDER BY`,
+			},
+		},
+		{
+			name:     "text search on a field that isn't text",
+			query:    `project = ABC AND labels ~ backend AND labels !~ frontend`,
+			warnings: []string{`~ on "labels", which isn't a text field`, `!~ on "labels", which isn't a text field`},
+		},
+		{
+			name:     "no project",
+			query:    `status = Open`,
+			warnings: []string{"query isn't limited to a project"},
+		},
+		{
+			name:     "project in OR",
+			query:    `project = ABC OR status = Open`,
+			warnings: []string{"query isn't limited to a project"},
+		},
+		{
+			name:     "negated project",
+			query:    `NOT project = ABC AND status = Open`,
+			warnings: []string{"query isn't limited to a project"},
+		},
+		{
+			name:  "project in group",
+			query: `(project = ABC OR project = DEF) AND status = Open`,
+		},
+		{
+			name:  "project in every OR",
+			query: `project = ABC AND status = Open OR project = DEF`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %s", err)
+			}
+			warnings, err := q.Lint(fields)
+			if err != nil {
+				t.Fatalf("failed to lint query: %s", err)
+			}
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("expected %d warnings, got %q", len(tt.warnings), warnings)
+			}
+			for i, w := range tt.warnings {
+				if !strings.HasPrefix(warnings[i], w) {
+					t.Fatalf("expected warning %q, got %q", w, warnings[i])
+				}
+			}
+		})
+	}
+
+	// Fields aren't checked without the fields of the instance
+	warnings, err := NewBuilder().Equals("project", "ABC").And().Contains("stauts", "Open").Lint(nil)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %q, %v", warnings, err)
+	}
+
+	// Invalid queries return the error from Build
+	_, err = NewBuilder().Equals("project", "ABC").And().Lint(nil)
+	if err == nil || !slices.Contains(strings.Split(err.Error(), "\n"), "Error: query cannot end with a keyword") {
+		t.Fatalf("expected the error from Build, got %v", err)
+	}
+}
@@ -0,0 +1,415 @@
+package jql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// wordValue is an unquoted value in a parsed query, like PRJ-1, 10 or -7d.
+type wordValue string
+
+func (w wordValue) jql() (string, error) {
+	return string(w), nil
+}
+
+// Parse parses a JQL query into a builder, with the same words as if the
+// query had been built with it, so it can be validated, formatted or combined
+// with other clauses. Syntax errors point at the line and column of the error.
+func Parse(jqlString string) (*JQLQueryBuilder, error) {
+	q := NewBuilder().SetJQLString(jqlString)
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q, nil
+}
+
+// parse parses the query into its words and the fields of its ORDER BY clause.
+func parse(query string) ([]jqlWord, []orderField, error) {
+	tokens, err := lex(query)
+	var words []jqlWord
+	var order []orderField
+	if err == nil {
+		p := &parser{tokens: tokens}
+		words, order, err = p.query()
+	}
+
+	var se *syntaxError
+	if errors.As(err, &se) {
+		return nil, nil, errorAt(query, se.pos, se.msg)
+	}
+	return words, order, err
+}
+
+// errorAt returns an error pointing at the line and column of pos in the
+// query, in the same style as the errors from Build.
+func errorAt(query string, pos int, msg string) error {
+	start := strings.LastIndexByte(query[:pos], '\n') + 1
+	end := len(query)
+	if n := strings.IndexByte(query[pos:], '\n'); n >= 0 {
+		end = pos + n
+	}
+	if end > pos && query[end-1] == '\r' {
+		end--
+	}
+	line := strings.Count(query[:start], "\n") + 1
+	column := utf8.RuneCountInString(query[start:pos]) + 1
+	return fmt.Errorf("invalid query at line %d, column %d:\n%s\nError: %s", line, column, pointer(query[start:end], pos-start), msg)
+}
+
+// pointer returns the text quoted, and a caret under the byte at pos on the
+// next line, counting the opening quote and any escaping before it. The
+// caret is placed by characters, so text that isn't ASCII doesn't shift it.
+func pointer(text string, pos int) string {
+	quotedPos := utf8.RuneCountInString(strconv.Quote(text[:pos])) - 1
+	return fmt.Sprintf("%q\n%s^", text, strings.Repeat(" ", quotedPos))
+}
+
+// parser is a recursive descent parser for JQL queries.
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+// next returns the next token and moves past it, unless it's the end.
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != eofToken {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &syntaxError{pos: t.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+// isWord reports whether the token is one of the words, ignoring case.
+func isWord(t token, words ...string) bool {
+	if t.kind != wordToken {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyword reports whether the token is a keyword that can't be a field or
+// a value.
+func isKeyword(t token) bool {
+	return isWord(t, "AND", "OR", "NOT", "ORDER")
+}
+
+// query parses the clauses and ORDER BY of a query.
+func (p *parser) query() ([]jqlWord, []orderField, error) {
+	var words []jqlWord
+	var err error
+	if t := p.peek(); t.kind != eofToken && !isWord(t, "ORDER") {
+		words, err = p.clauses()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var order []orderField
+	if isWord(p.peek(), "ORDER") {
+		order, err = p.orderBy()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch t := p.peek(); t.kind {
+	case eofToken:
+		return words, order, nil
+	case rparenToken:
+		return nil, nil, p.errorf(t, "unexpected %s, missing ( before it", t)
+	default:
+		return nil, nil, p.errorf(t, "expected AND, OR or ORDER BY, got %s", t)
+	}
+}
+
+// clauses parses clauses joined by AND and OR, and groups of them.
+func (p *parser) clauses() ([]jqlWord, error) {
+	var words []jqlWord
+	for {
+		for isWord(p.peek(), "NOT") {
+			p.next()
+			words = append(words, Not)
+		}
+
+		if p.peek().kind == lparenToken {
+			p.next()
+			group, err := p.clauses()
+			if err != nil {
+				return nil, err
+			}
+			switch t := p.next(); {
+			case isWord(t, "ORDER"):
+				return nil, p.errorf(t, "%q cannot be used in a group", OrderByKeyword.String())
+			case t.kind != rparenToken:
+				return nil, p.errorf(t, "expected AND, OR or ), got %s", t)
+			}
+			words = append(words, Group{words: group})
+		} else {
+			op, err := p.clause()
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, op)
+		}
+
+		switch t := p.peek(); {
+		case isWord(t, "AND"):
+			words = append(words, And)
+		case isWord(t, "OR"):
+			words = append(words, Or)
+		default:
+			return words, nil
+		}
+		p.next()
+	}
+}
+
+// clause parses a field, an operator and its values.
+func (p *parser) clause() (Operator, error) {
+	var op Operator
+	t := p.next()
+	switch {
+	case t.kind == stringToken:
+		op.field = t.text
+	case t.kind != wordToken || isKeyword(t):
+		return op, p.errorf(t, "expected a field, got %s", t)
+	case reservedWords[strings.ToLower(t.text)]:
+		return op, p.errorf(t, "%s is a reserved word, quote it to use it as a field", t)
+	default:
+		op.field, op.verbatim = t.text, true
+	}
+
+	var err error
+	t = p.next()
+	switch {
+	case t.kind == operatorToken:
+		op.operator = t.text
+		op.value, err = p.value()
+	case isWord(t, "IN"):
+		op.operator = "IN"
+		op.value, err = p.list(op.operator)
+	case isWord(t, "NOT"):
+		if t := p.next(); !isWord(t, "IN") {
+			return op, p.errorf(t, "expected IN after NOT, got %s", t)
+		}
+		op.operator = "NOT IN"
+		op.value, err = p.list(op.operator)
+	case isWord(t, "IS"):
+		op.operator = "IS"
+		if isWord(p.peek(), "NOT") {
+			p.next()
+			op.operator = "IS NOT"
+		}
+		v := p.next()
+		if !isWord(v, "EMPTY", "NULL") {
+			return op, p.errorf(v, "expected EMPTY or NULL after %s, got %s", op.operator, v)
+		}
+		op.value = strings.ToUpper(v.text)
+	case isWord(t, "WAS"):
+		op.operator = "WAS"
+		if isWord(p.peek(), "NOT") {
+			p.next()
+			op.operator += " NOT"
+		}
+		if isWord(p.peek(), "IN") {
+			p.next()
+			op.operator += " IN"
+			op.value, err = p.list(op.operator)
+		} else {
+			op.value, err = p.value()
+		}
+		if err == nil {
+			err = p.predicates(&op)
+		}
+	case isWord(t, "CHANGED"):
+		op.operator = "CHANGED"
+		err = p.predicates(&op)
+	default:
+		return op, p.errorf(t, "expected an operator after %q, got %s", op.field, t)
+	}
+	return op, err
+}
+
+// value parses a single value, which can be a function call.
+func (p *parser) value() (string, error) {
+	t := p.next()
+	var v Value
+	switch {
+	case t.kind == stringToken:
+		v = String(t.text)
+	case isWord(t, "EMPTY", "NULL"):
+		v = keywordValue(strings.ToUpper(t.text))
+	case t.kind == wordToken && p.peek().kind == lparenToken:
+		fn, err := p.function(t)
+		if err != nil {
+			return "", err
+		}
+		v = fn
+	case t.kind == wordToken && !isKeyword(t):
+		v = wordValue(t.text)
+	default:
+		return "", p.errorf(t, "expected a value, got %s", t)
+	}
+
+	s, err := v.jql()
+	if err != nil {
+		return "", p.errorf(t, "%s", err)
+	}
+	return s, nil
+}
+
+// function parses the arguments of a function call.
+func (p *parser) function(name token) (function, error) {
+	fn := function{name: name.text}
+	p.next()
+	if p.peek().kind == rparenToken {
+		p.next()
+		return fn, nil
+	}
+	for {
+		t := p.next()
+		if t.kind != wordToken && t.kind != stringToken {
+			return fn, p.errorf(t, "expected an argument to %s(), got %s", fn.name, t)
+		}
+		fn.args = append(fn.args, t.text)
+
+		switch t := p.next(); t.kind {
+		case commaToken:
+		case rparenToken:
+			return fn, nil
+		default:
+			return fn, p.errorf(t, "expected , or ) in %s(), got %s", fn.name, t)
+		}
+	}
+}
+
+// list parses a list of values in parentheses, or a function returning one.
+func (p *parser) list(operator string) (string, error) {
+	t := p.peek()
+	if t.kind == wordToken && p.tokens[p.i+1].kind == lparenToken {
+		return p.value()
+	}
+	if t.kind != lparenToken {
+		return "", p.errorf(t, "expected a list of values in parentheses after %s, got %s", operator, t)
+	}
+	p.next()
+	if t := p.peek(); t.kind == rparenToken {
+		return "", p.errorf(t, "%s requires at least one value", operator)
+	}
+
+	var values []string
+	for {
+		v, err := p.value()
+		if err != nil {
+			return "", err
+		}
+		values = append(values, v)
+
+		switch t := p.next(); t.kind {
+		case commaToken:
+		case rparenToken:
+			return "(" + strings.Join(values, ", ") + ")", nil
+		default:
+			return "", p.errorf(t, "expected , or ) in the list of values, got %s", t)
+		}
+	}
+}
+
+// predicates parses the predicates of a history operator, like BY 'alice'.
+func (p *parser) predicates(op *Operator) error {
+	for {
+		t := p.peek()
+		name := strings.ToUpper(t.text)
+		if t.kind != wordToken || !slices.Contains(changedPredicates, name) {
+			return nil
+		}
+		p.next()
+		if predicates := historyPredicates[op.operator]; !slices.Contains(predicates, name) {
+			return p.errorf(t, "%s can't be used with %s, expected one of %s", name, op.operator, strings.Join(predicates, ", "))
+		}
+
+		var value string
+		var err error
+		if name == "DURING" {
+			value, err = p.during()
+		} else {
+			value, err = p.value()
+		}
+		if err != nil {
+			return err
+		}
+		op.predicates = append(op.predicates, name+" "+value)
+	}
+}
+
+// during parses the two dates of a DURING predicate.
+func (p *parser) during() (string, error) {
+	if t := p.next(); t.kind != lparenToken {
+		return "", p.errorf(t, "expected ( after DURING, got %s", t)
+	}
+	from, err := p.value()
+	if err != nil {
+		return "", err
+	}
+	if t := p.next(); t.kind != commaToken {
+		return "", p.errorf(t, "expected , between the dates of DURING, got %s", t)
+	}
+	to, err := p.value()
+	if err != nil {
+		return "", err
+	}
+	if t := p.next(); t.kind != rparenToken {
+		return "", p.errorf(t, "expected ) after the dates of DURING, got %s", t)
+	}
+	return "(" + from + ", " + to + ")", nil
+}
+
+// orderBy parses the fields of an ORDER BY clause, which ends the query.
+func (p *parser) orderBy() ([]orderField, error) {
+	p.next()
+	if t := p.next(); !isWord(t, "BY") {
+		return nil, p.errorf(t, "expected BY after ORDER, got %s", t)
+	}
+
+	var o OrderBy
+	for {
+		t := p.next()
+		if (t.kind != wordToken && t.kind != stringToken) || isKeyword(t) {
+			return nil, p.errorf(t, "expected a field to order by, got %s", t)
+		}
+		if o.has(t.text) {
+			return nil, p.errorf(t, "field %q is already in %s", t.text, OrderByKeyword.String())
+		}
+		f := orderField{field: t.text, verbatim: t.kind == wordToken}
+		if isWord(p.peek(), "ASC", "DESC") {
+			f.direction = strings.ToUpper(p.next().text)
+		}
+		o.fields = append(o.fields, f)
+
+		switch t := p.peek(); t.kind {
+		case commaToken:
+			p.next()
+		case eofToken:
+			return o.fields, nil
+		default:
+			return nil, p.errorf(t, "expected , or the end of the query after the %s fields, got %s", OrderByKeyword.String(), t)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package jql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testData := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "simple",
+			query:    `project = ABC AND status in ("In Progress", 'To Do') order by priority desc, created`,
+			expected: `project = ABC AND status IN ('In Progress', 'To Do') ORDER BY priority DESC, created`,
+		},
+		{
+			name:     "groups and symbols",
+			query:    `assignee=currentUser() && NOT (type = Bug || priority >= High)`,
+			expected: `assignee = currentUser() AND NOT (type = Bug OR priority >= High)`,
+		},
+		{
+			name:     "quoted and custom fields",
+			query:    `"Story Points" > 3 AND cf[10010] is not empty AND summary !~ 'it\'s broken'`,
+			expected: `"Story Points" > 3 AND cf[10010] IS NOT EMPTY AND summary !~ 'it\'s broken'`,
+		},
+		{
+			name:     "history",
+			query:    `status changed from "Open" to Done by currentUser() during ("2024-01-01", now()) or status was not in (Open, Reopened) after -7d`,
+			expected: `status CHANGED FROM 'Open' TO Done BY currentUser() DURING ('2024-01-01', now()) OR status WAS NOT IN (Open, Reopened) AFTER -7d`,
+		},
+		{
+			name:     "functions",
+			query:    `issue in linkedIssues(PRJ-1, "is blocked by") and sprint in openSprints() and updated >= startOfWeek(-1w)`,
+			expected: `issue IN linkedIssues(PRJ-1, "is blocked by") AND sprint IN openSprints() AND updated >= startOfWeek(-1w)`,
+		},
+		{
+			name:     "multiple lines",
+			query:    "project = ABC\n  AND (status = Open\n    OR status = \"To Do\")\nORDER BY created",
+			expected: `project = ABC AND (status = Open OR status = 'To Do') ORDER BY created`,
+		},
+		{
+			name:     "entity properties",
+			query:    `issue.property[support].priority = 1 AND project.property[config].team in (a, b) ORDER BY issue.property[support].priority`,
+			expected: `issue.property[support].priority = 1 AND project.property[config].team IN (a, b) ORDER BY issue.property[support].priority`,
+		},
+		{
+			name:     "unusual field names",
+			query:    `customfield_10010 = 1 AND Größe = L AND "Epic Link" = PRJ-1 AND labels = foo!bar AND labels != a&b|c`,
+			expected: `customfield_10010 = 1 AND Größe = L AND "Epic Link" = PRJ-1 AND labels = foo!bar AND labels != a&b|c`,
+		},
+		{
+			name:     "operators after words",
+			query:    `labels!=foo&&status=Open||!(type=Bug)`,
+			expected: `labels != foo AND status = Open OR NOT (type = Bug)`,
+		},
+		{
+			name:     "only ORDER BY",
+			query:    "ORDER BY created DESC",
+			expected: "ORDER BY created DESC",
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %s", err)
+			}
+			s, err := q.Build()
+			if err != nil {
+				t.Fatalf("failed to build query: %s", err)
+			}
+			if s != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testData := []struct {
+		name     string
+		query    string
+		position string
+		errMsg   string
+	}{
+		{
+			name:     "missing value",
+			query:    "status = Open\nAND priority = ",
+			position: "line 2, column 16",
+			errMsg:   "expected a value, got end of query",
+		},
+		{
+			name:     "unterminated string",
+			query:    `status = "Open`,
+			position: "line 1, column 10",
+			errMsg:   "unterminated string",
+		},
+		{
+			name:     "missing keyword",
+			query:    "status = Open priority = High",
+			position: "line 1, column 15",
+			errMsg:   `expected AND, OR or ORDER BY, got "priority"`,
+		},
+		{
+			name:     "ORDER BY in group",
+			query:    "(status = Open ORDER BY created)",
+			position: "line 1, column 16",
+			errMsg:   "cannot be used in a group",
+		},
+		{
+			name:     "function arguments",
+			query:    "assignee = currentUser(bob)",
+			position: "line 1, column 12",
+			errMsg:   "currentUser() takes 0 arguments, got 1",
+		},
+		{
+			name:     "empty list",
+			query:    "status in ()",
+			position: "line 1, column 12",
+			errMsg:   "IN requires at least one value",
+		},
+		{
+			name:     "predicate",
+			query:    "status was Open from Done",
+			position: "line 1, column 17",
+			errMsg:   "FROM can't be used with WAS",
+		},
+		{
+			name:     "reserved word",
+			query:    "type = Bug AND select = 1",
+			position: "line 1, column 16",
+			errMsg:   `"select" is a reserved word, quote it`,
+		},
+		{
+			name:     "unbalanced parentheses",
+			query:    "status = Open)",
+			position: "line 1, column 14",
+			errMsg:   "missing ( before it",
+		},
+		{
+			name:     "single ampersand",
+			query:    "status = Open & type = Bug",
+			position: "line 1, column 15",
+			errMsg:   `did you mean "&&"?`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.position) {
+				t.Fatalf("expected error at %s, got %q", tt.position, err.Error())
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error message to contain %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseErrorCaret(t *testing.T) {
+	_, err := Parse("project = ABC\nAND status in (Open,)")
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	expected := []string{
+		"invalid query at line 2, column 21:",
+		`"AND status in (Open,)"`,
+		strings.Repeat(" ", 21) + "^",
+		`Error: expected a value, got ")"`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected %q, got %q", expected, lines)
+	}
+}
+
+func TestParseErrorCaretNonASCII(t *testing.T) {
+	_, err := Parse(`labels = "café" AND x = `)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	expected := []string{
+		"invalid query at line 1, column 25:",
+		`"labels = \"café\" AND x = "`,
+		// The quoted prefix is 27 characters, with the escaped quotes
+		strings.Repeat(" ", 27) + "^",
+		"Error: expected a value, got end of query",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected %q, got %q", expected, lines)
+	}
+}
+
+func TestGroupJQL(t *testing.T) {
+	s, err := NewBuilder().
+		GroupJQL("assignee = currentUser() OR reporter = currentUser() ORDER BY updated DESC").
+		And().Equals("project", "ABC").
+		OrderByDesc("priority", "Updated").
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build query: %s", err)
+	}
+
+	// The ORDER BY of the group is moved to the end, without the field the
+	// builder already orders by.
+	expected := `(assignee = currentUser() OR reporter = currentUser()) AND project = 'ABC' ORDER BY priority DESC, Updated DESC`
+	if s != expected {
+		t.Fatalf("expected %q, got %q", expected, s)
+	}
+
+	// A query that's only an ORDER BY keeps its order, without a group.
+	for _, q := range []*JQLQueryBuilder{
+		NewBuilder().GroupJQL("ORDER BY created").And().Equals("project", "ABC"),
+		NewBuilder().Equals("project", "ABC").And().GroupJQL("ORDER BY created"),
+	} {
+		s, err := q.Build()
+		if err != nil {
+			t.Fatalf("failed to build query: %s", err)
+		}
+		if expected := `project = 'ABC' ORDER BY created`; s != expected {
+			t.Fatalf("expected %q, got %q", expected, s)
+		}
+	}
+
+	_, err = NewBuilder().GroupJQL("status = ").And().Equals("project", "ABC").Build()
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 10") {
+		t.Fatalf("expected syntax error of the group, got %v", err)
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	testData := []struct {
+		name     string
+		q        *JQLQueryBuilder
+		expected bool
+	}{
+		{name: "ORDER BY in a value", q: NewBuilder().SetJQLString(`summary ~ 'order by'`), expected: false},
+		{name: "parsed ORDER BY", q: NewBuilder().SetJQLString(`project = ABC order  by created`), expected: true},
+		{name: "builder ORDER BY", q: NewBuilder().Equals("project", "ABC").OrderByDesc("created"), expected: true},
+		{name: "group ORDER BY", q: NewBuilder().GroupJQL("project = ABC ORDER BY created"), expected: true},
+		{name: "no ORDER BY", q: NewBuilder().Equals("project", "ABC"), expected: false},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			if ordered := tt.q.Ordered(); ordered != tt.expected {
+				t.Fatalf("expected %t, got %t", tt.expected, ordered)
+			}
+		})
+	}
+}
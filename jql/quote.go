@@ -0,0 +1,70 @@
+package jql
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// identifierRe matches field names that don't need quoting.
+	identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	// customFieldRe matches custom field references like cf[10010].
+	customFieldRe = regexp.MustCompile(`^cf\[\d+\]$`)
+
+	valueEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	fieldEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+)
+
+// reservedWords can't be used as field names without quoting them.
+// https://support.atlassian.com/jira-service-management-cloud/docs/use-advanced-search-with-jira-query-language-jql/
+var reservedWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a an abort access add after alias all alter and any are as asc audit avg
+		before begin between boolean break by byte catch cf char character check
+		checkpoint collate collation column commit connect continue count create
+		current date decimal declare decrement default defaults define delete
+		delimiter desc difference distinct divide do double drop else empty
+		encoding end equals escape exclusive exec execute exists explain false
+		fetch file field first float for from function go goto grant greater group
+		having identified if immediate in increment index initial inner inout
+		input insert int integer intersect intersection into is isempty isnull
+		join last left less like limit lock long max min minus mode modify modulo
+		more multiply next noaudit not notin nowait null number object of on
+		option or order outer output power previous prior privileges public raise
+		raw remainder rename resource return returns revoke right row rowid rownum
+		rows select session set share size sqrt start strict string subtract sum
+		synonym table then to trans transaction trigger true uid union unique
+		update user validate values view was when whenever where while with`) {
+		reservedWords[w] = true
+	}
+}
+
+// quoteValue quotes a value in single quotes, escaping quotes, backslashes
+// and control characters so the value can't end the string early.
+func quoteValue(v string) string {
+	return "'" + valueEscaper.Replace(v) + "'"
+}
+
+// quoteField returns the field name as is if it's a plain identifier or a
+// custom field reference like cf[10010], and in double quotes otherwise,
+// e.g. for names with spaces like "Story Points" or reserved words.
+func quoteField(f string) string {
+	if customFieldRe.MatchString(f) {
+		return f
+	}
+	if identifierRe.MatchString(f) && !reservedWords[strings.ToLower(f)] {
+		return f
+	}
+	return `"` + fieldEscaper.Replace(f) + `"`
+}
+
+// writeField returns the field name quoted with quoteField, or as is if it's
+// verbatim.
+func writeField(f string, verbatim bool) string {
+	if verbatim {
+		return f
+	}
+	return quoteField(f)
+}
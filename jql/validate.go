@@ -0,0 +1,87 @@
+package jql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is returned for the errors JIRA finds in a query that the
+// parser can't, like unknown fields, or values of the wrong type for a field.
+type ValidationError struct {
+	// Query is the query that was validated.
+	Query string
+	// Messages are the error messages from JIRA.
+	Messages []string
+}
+
+// Error returns the messages, pointing at the line and column of the query
+// they're about when it can be found.
+func (e *ValidationError) Error() string {
+	errs := make([]string, len(e.Messages))
+	for i, msg := range e.Messages {
+		errs[i] = messageError(e.Query, msg).Error()
+	}
+	return strings.Join(errs, "\n")
+}
+
+var (
+	// positionRe matches the position JIRA adds to syntax errors, like
+	// "(line 1, character 15)". The character is counted from 1.
+	positionRe = regexp.MustCompile(`\s*\(line (\d+), character (\d+)\)`)
+	// quotedRe matches the field, value or function an error is about, like
+	// "Field 'stauts' does not exist".
+	quotedRe = regexp.MustCompile(`'([^']+)'`)
+)
+
+// messageError returns an error with the message from JIRA, pointing at the
+// position it gives, or otherwise at the first token of the query that the
+// message quotes, trying the quoted names in order.
+func messageError(query string, msg string) error {
+	if m := positionRe.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		column, _ := strconv.Atoi(m[2])
+		if pos, ok := position(query, line, column); ok {
+			return errorAt(query, pos, strings.Replace(msg, m[0], "", 1))
+		}
+	}
+
+	if tokens, err := lex(query); err == nil {
+		for _, m := range quotedRe.FindAllStringSubmatch(msg, -1) {
+			for _, t := range tokens {
+				if t.kind != eofToken && strings.EqualFold(t.text, m[1]) {
+					return errorAt(query, t.pos, msg)
+				}
+			}
+		}
+	}
+	return fmt.Errorf("invalid query: %s", msg)
+}
+
+// position returns the byte position of a line and column in the query,
+// both counted from 1.
+func position(query string, line int, column int) (int, bool) {
+	if line < 1 || column < 1 {
+		return 0, false
+	}
+	start := 0
+	for range line - 1 {
+		n := strings.IndexByte(query[start:], '\n')
+		if n < 0 {
+			return 0, false
+		}
+		start += n + 1
+	}
+
+	pos := start
+	for range column - 1 {
+		if pos >= len(query) || query[pos] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(query[pos:])
+		pos += size
+	}
+	return pos, true
+}
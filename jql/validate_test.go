@@ -0,0 +1,63 @@
+package jql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidationError(t *testing.T) {
+	testData := []struct {
+		name     string
+		query    string
+		message  string
+		expected []string
+	}{
+		{
+			name:    "position",
+			query:   "project = ABC\nAND status = ",
+			message: "Error in the JQL Query: Expecting either a value, list or function but got 'EOF'. (line 2, character 14)",
+			expected: []string{
+				"invalid query at line 2, column 14:",
+				`"AND status = "`,
+				strings.Repeat(" ", 14) + "^",
+				"Error: Error in the JQL Query: Expecting either a value, list or function but got 'EOF'.",
+			},
+		},
+		{
+			name:    "quoted field",
+			query:   `project = ABC AND Stauts = "Open"`,
+			message: "Field 'stauts' does not exist or you do not have permission to view it.",
+			expected: []string{
+				"invalid query at line 1, column 19:",
+				`"project = ABC AND Stauts = \"Open\""`,
+				strings.Repeat(" ", 19) + "^",
+				"Error: Field 'stauts' does not exist or you do not have permission to view it.",
+			},
+		},
+		{
+			name:     "not in the query",
+			query:    "project = ABC",
+			message:  "The value 'XYZ' does not exist for the field 'project'.",
+			expected: []string{"invalid query at line 1, column 1:"},
+		},
+		{
+			name:     "no position",
+			query:    "project = ABC",
+			message:  "Something went wrong.",
+			expected: []string{"invalid query: Something went wrong."},
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &ValidationError{Query: tt.query, Messages: []string{tt.message}}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) > len(tt.expected) {
+				lines = lines[:len(tt.expected)]
+			}
+			if strings.Join(lines, "\n") != strings.Join(tt.expected, "\n") {
+				t.Fatalf("expected %q, got %q", tt.expected, strings.Split(err.Error(), "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package jql
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Value is a value in a clause, such as a string, a relative date or a
+// function call. Values are rendered as JQL when the query is built.
+type Value interface {
+	// jql returns the value as it's written in JQL, or an error if the value
+	// is invalid.
+	jql() (string, error)
+}
+
+// stringValue is a quoted string.
+type stringValue string
+
+func (s stringValue) jql() (string, error) {
+	return quoteValue(string(s)), nil
+}
+
+// String returns a string value, which is quoted and escaped.
+func String(s string) Value {
+	return stringValue(s)
+}
+
+// Strings returns string values for each of the strings.
+func Strings(s ...string) []Value {
+	values := make([]Value, len(s))
+	for i, v := range s {
+		values[i] = String(v)
+	}
+	return values
+}
+
+// keywordValue is a value keyword, like EMPTY.
+type keywordValue string
+
+func (k keywordValue) jql() (string, error) {
+	return string(k), nil
+}
+
+// Empty and Null are the values of IS and IS NOT, e.g. assignee IS EMPTY.
+var (
+	Empty Value = keywordValue("EMPTY")
+	Null  Value = keywordValue("NULL")
+)
+
+// relativeDateRe matches relative dates like -7d, 2w or +4h.
+var relativeDateRe = regexp.MustCompile(`^[-+]?\d+[yMwdhm]$`)
+
+// relativeDate is a date relative to now, rendered unquoted.
+type relativeDate string
+
+func (d relativeDate) jql() (string, error) {
+	if !relativeDateRe.MatchString(string(d)) {
+		return string(d), fmt.Errorf("invalid relative date %q, expected a number and a unit like -7d, 2w or +4h", string(d))
+	}
+	return string(d), nil
+}
+
+// RelativeDate returns a date relative to now, such as "-7d" for a week ago
+// or "+4h" in four hours. The units are y, M (months), w, d, h and m (minutes).
+func RelativeDate(d string) Value {
+	return relativeDate(d)
+}
+
+// funcArgRe matches function arguments that don't need quoting.
+var funcArgRe = regexp.MustCompile(`^[A-Za-z0-9_.+\-]+$`)
+
+// function is a JQL function call, like currentUser().
+type function struct {
+	name string
+	args []string
+}
+
+// funcArgs is the minimum and maximum number of arguments of a function.
+// A maximum of -1 means any number.
+type funcArgs struct {
+	min, max int
+}
+
+// functions are the JQL functions with known arguments, to validate calls.
+// https://support.atlassian.com/jira-software-cloud/docs/jql-functions/
+var functions = map[string]funcArgs{
+	"approved":                        {0, 0},
+	"approver":                        {1, -1},
+	"cascadeOption":                   {1, 2},
+	"closedSprints":                   {0, 0},
+	"componentsLeadByUser":            {0, 1},
+	"currentLogin":                    {0, 0},
+	"currentUser":                     {0, 0},
+	"earliestUnreleasedVersion":       {1, 1},
+	"endOfDay":                        {0, 1},
+	"endOfMonth":                      {0, 1},
+	"endOfWeek":                       {0, 1},
+	"endOfYear":                       {0, 1},
+	"futureSprints":                   {0, 0},
+	"issueHistory":                    {0, 0},
+	"issuesWithRemoteLinksByGlobalId": {1, 100},
+	"lastLogin":                       {0, 0},
+	"latestReleasedVersion":           {1, 1},
+	"linkedIssues":                    {1, 2},
+	"membersOf":                       {1, 1},
+	"now":                             {0, 0},
+	"openSprints":                     {0, 0},
+	"parentEpic":                      {1, 1},
+	"projectsLeadByUser":              {0, 1},
+	"projectsWhereUserHasPermission":  {1, 1},
+	"projectsWhereUserHasRole":        {1, 1},
+	"releasedVersions":                {0, 1},
+	"standardIssueTypes":              {0, 0},
+	"startOfDay":                      {0, 1},
+	"startOfMonth":                    {0, 1},
+	"startOfWeek":                     {0, 1},
+	"startOfYear":                     {0, 1},
+	"subtaskIssueTypes":               {0, 0},
+	"unreleasedVersions":              {0, 1},
+	"updatedBy":                       {1, 3},
+	"votedIssues":                     {0, 0},
+	"watchedIssues":                   {0, 0},
+}
+
+func (f function) jql() (string, error) {
+	args := make([]string, len(f.args))
+	for i, a := range f.args {
+		if funcArgRe.MatchString(a) {
+			args[i] = a
+		} else {
+			args[i] = `"` + fieldEscaper.Replace(a) + `"`
+		}
+	}
+	s := f.name + "(" + strings.Join(args, ", ") + ")"
+
+	if !funcArgRe.MatchString(f.name) {
+		return s, fmt.Errorf("invalid function name %q", f.name)
+	}
+	if spec, ok := functions[f.name]; ok {
+		n := len(f.args)
+		if n < spec.min || (spec.max >= 0 && n > spec.max) {
+			return s, fmt.Errorf("%s() takes %s, got %d", f.name, spec, n)
+		}
+	}
+	return s, nil
+}
+
+func (a funcArgs) String() string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case a.min == a.max:
+		return plural(a.min)
+	case a.max < 0:
+		return "at least " + plural(a.min)
+	default:
+		return fmt.Sprintf("%d to %d arguments", a.min, a.max)
+	}
+}
+
+// Func returns a call to the JQL function with the arguments. Arguments are
+// quoted if needed. The number of arguments is validated for the built-in
+// JQL functions, any other function is assumed to be provided by an app.
+//
+// Func("linkedIssues", "PRJ-1", "is blocked by") renders as
+// linkedIssues(PRJ-1, "is blocked by").
+func Func(name string, args ...string) Value {
+	return function{name: name, args: args}
+}
+
+// CurrentUser is the user running the query, currentUser().
+func CurrentUser() Value { return Func("currentUser") }
+
+// OpenSprints are the active sprints, openSprints().
+func OpenSprints() Value { return Func("openSprints") }
+
+// ClosedSprints are the completed sprints, closedSprints().
+func ClosedSprints() Value { return Func("closedSprints") }
+
+// FutureSprints are the sprints that haven't started, futureSprints().
+func FutureSprints() Value { return Func("futureSprints") }
+
+// Now is the current time, now().
+func Now() Value { return Func("now") }
+
+// StartOfDay is the start of the current day, optionally offset by a
+// relative date like "-1d".
+func StartOfDay(offset ...string) Value { return Func("startOfDay", offset...) }
+
+// StartOfWeek is the start of the current week, optionally offset by a
+// relative date like "-1w".
+func StartOfWeek(offset ...string) Value { return Func("startOfWeek", offset...) }
+
+// StartOfMonth is the start of the current month, optionally offset by a
+// relative date like "-1M".
+func StartOfMonth(offset ...string) Value { return Func("startOfMonth", offset...) }
+
+// StartOfYear is the start of the current year, optionally offset.
+func StartOfYear(offset ...string) Value { return Func("startOfYear", offset...) }
+
+// EndOfDay is the end of the current day, optionally offset.
+func EndOfDay(offset ...string) Value { return Func("endOfDay", offset...) }
+
+// EndOfWeek is the end of the current week, optionally offset.
+func EndOfWeek(offset ...string) Value { return Func("endOfWeek", offset...) }
+
+// EndOfMonth is the end of the current month, optionally offset.
+func EndOfMonth(offset ...string) Value { return Func("endOfMonth", offset...) }
+
+// EndOfYear is the end of the current year, optionally offset.
+func EndOfYear(offset ...string) Value { return Func("endOfYear", offset...) }
+
+// MembersOf are the members of the group, membersOf(group).
+func MembersOf(group string) Value { return Func("membersOf", group) }
+
+// LinkedIssues are the issues linked to the issue, optionally only with the
+// link type like "is blocked by".
+func LinkedIssues(key string, linkType ...string) Value {
+	return Func("linkedIssues", append([]string{key}, linkType...)...)
+}
+
+// ParentEpic is the epic of the issue and the issue itself, parentEpic(key).
+func ParentEpic(key string) Value { return Func("parentEpic", key) }
+
+// WatchedIssues are the issues the current user watches, watchedIssues().
+func WatchedIssues() Value { return Func("watchedIssues") }
+
+// operatorValues is how many values each operator takes with Cond.
+// A maximum of -1 means a list of any length.
+var operatorValues = map[string]funcArgs{
+	"=": {1, 1}, "!=": {1, 1}, ">": {1, 1}, ">=": {1, 1}, "<": {1, 1}, "<=": {1, 1},
+	"~": {1, 1}, "!~": {1, 1},
+	"IN": {1, -1}, "NOT IN": {1, -1},
+	"IS": {1, 1}, "IS NOT": {1, 1},
+	"WAS": {1, 1}, "WAS NOT": {1, 1},
+	"WAS IN": {1, -1}, "WAS NOT IN": {1, -1},
+	"CHANGED": {0, 0},
+}
+
+// Cond adds a clause with the field, operator and values, for values that
+// aren't plain strings like functions and relative dates.
+// Operators that take a list of values, like IN, render them in parentheses.
+//
+// Cond("assignee", "=", CurrentUser()) renders as assignee = currentUser()
+// Cond("updated", ">=", RelativeDate("-7d")) renders as updated >= -7d
+func (q *JQLQueryBuilder) Cond(field string, operator string, values ...Value) *JQLQueryBuilder {
+	op := Operator{field: field, operator: strings.ToUpper(operator)}
+
+	rendered := make([]string, len(values))
+	for i, v := range values {
+		s, err := v.jql()
+		if err != nil && op.err == nil {
+			op.err = err
+		}
+		rendered[i] = s
+	}
+
+	spec, ok := operatorValues[op.operator]
+	switch {
+	case !ok:
+		keys := make([]string, 0, len(operatorValues))
+		for k := range operatorValues {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		op.err = fmt.Errorf("unknown operator %q, expected one of %s", operator, strings.Join(keys, ", "))
+	case len(values) < spec.min || (spec.max >= 0 && len(values) > spec.max):
+		op.err = fmt.Errorf("%s takes %s, got %d", op.operator, strings.ReplaceAll(spec.String(), "argument", "value"), len(values))
+	case (op.operator == "IS" || op.operator == "IS NOT") && values[0] != Empty && values[0] != Null:
+		op.err = fmt.Errorf("%s only takes EMPTY or NULL", op.operator)
+	}
+
+	switch {
+	case spec.max < 0:
+		op.value = "(" + strings.Join(rendered, ", ") + ")"
+	case len(rendered) > 0:
+		op.value = strings.Join(rendered, ", ")
+	}
+
+	q.qt = append(q.qt, op)
+	return q
+}
@@ -0,0 +1,43 @@
+package jt
+
+// SavedQuery is a named query from the config, run with "jt query <name>".
+// The query is either raw JQL, a JIRA saved filter, or built from the
+// filter fields, which work like and are named after the flags of jt query.
+type SavedQuery struct {
+	// Description is shown when listing the saved queries.
+	Description string `yaml:"description"`
+	// JQL is a raw JQL query.
+	JQL string `yaml:"jql"`
+	// Filter is the ID of a JIRA saved filter to run.
+	Filter string `yaml:"filter"`
+
+	// Projects scope the query to these projects instead of the default
+	// project and components.
+	Projects []string `yaml:"project"`
+	// AllProjects drops the default project and components scoping.
+	AllProjects bool `yaml:"allProjects"`
+	// Statuses, Types and Labels match issues with any of the values.
+	Statuses []string `yaml:"status"`
+	Types    []string `yaml:"type"`
+	Labels   []string `yaml:"label"`
+	// Assignee can be "me", an account ID, a name or an email.
+	Assignee string `yaml:"assignee"`
+	// UpdatedSince is a duration like "7d" or a date like "2024-10-01".
+	UpdatedSince string `yaml:"updatedSince"`
+
+	// Columns are the table and tsv columns. A saved query with columns is
+	// printed as a table unless --output is set.
+	Columns []string `yaml:"columns"`
+	// OrderBy sorts the results by these fields, as "field" or "field:desc".
+	OrderBy []string `yaml:"orderBy"`
+	// Limit is the maximum number of issues to return, or 0 for all of them.
+	Limit int `yaml:"limit"`
+}
+
+// Filter is a JIRA saved filter.
+type Filter struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	JQL         string `json:"jql"`
+}
@@ -0,0 +1,165 @@
+package jt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const templateFileExt = ".yaml"
+
+// IssueTemplate is a named template used to pre-fill issues.
+// Templates are defined under "templates" in the config file, or as
+// <name>.yaml files in the templates directory.
+type IssueTemplate struct {
+	// Summary is the issue summary. It's a Go text/template, see TemplateData for
+	// the available variables.
+	Summary string `yaml:"summary"`
+	// Description is the issue description. It's a Go text/template, see
+	// TemplateData for the available variables.
+	Description string `yaml:"description"`
+	// IssueType overrides the default issue type.
+	IssueType string `yaml:"issueType"`
+	// Labels are added to the issue.
+	Labels []string `yaml:"labels"`
+	// ComponentNames overrides the default components.
+	ComponentNames []string `yaml:"componentNames"`
+	// CustomFields are set on the issue as is, keyed by field ID.
+	// Example: customfield_10010: 3
+	CustomFields map[string]any `yaml:"customFields"`
+}
+
+// TemplateData holds the variables available to issue templates.
+type TemplateData struct {
+	// Summary is the summary provided on the command line, if any.
+	Summary string
+	// Branch is the current git branch, if any.
+	Branch string
+	// Date is the current date, formatted as YYYY-MM-DD.
+	Date string
+	// User is the username of the current user.
+	User string
+	// Email is the JIRA user email from the config.
+	Email string
+	// Project is the default project key from the config.
+	Project string
+}
+
+// NewTemplateData returns the template variables for the current environment.
+func NewTemplateData(conf JTConfig, summary string) TemplateData {
+	td := TemplateData{
+		Summary: summary,
+		Date:    time.Now().Format(time.DateOnly),
+		Email:   conf.Email,
+		Project: conf.DefaultProjectKey,
+	}
+
+	if usr, err := user.Current(); err == nil {
+		td.User = usr.Username
+	}
+
+	// Not being in a git repository is fine, the branch is left empty.
+	if out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+		td.Branch = strings.TrimSpace(string(out))
+	}
+
+	return td
+}
+
+// LoadTemplate returns the named template. Templates in the config file take
+// precedence over templates in the templates directory.
+func LoadTemplate(conf JTConfig, name string) (IssueTemplate, error) {
+	if t, ok := conf.Templates[name]; ok {
+		return t, nil
+	}
+
+	t := IssueTemplate{}
+	if conf.TemplatesDir == "" {
+		return t, fmt.Errorf("template %q not found", name)
+	}
+
+	dir, err := expandPath(conf.TemplatesDir)
+	if err != nil {
+		return t, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, name+templateFileExt))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return t, fmt.Errorf("template %q not found in config or %s", name, dir)
+		}
+		return t, fmt.Errorf("failed to open template: %w", err)
+	}
+	defer f.Close()
+
+	if err := yaml.NewDecoder(f).Decode(&t); err != nil {
+		return t, fmt.Errorf("failed to decode template %q: %w", name, err)
+	}
+	return t, nil
+}
+
+// Render executes the summary and description templates with the provided data.
+func (t IssueTemplate) Render(data TemplateData) (IssueTemplate, error) {
+	var err error
+	t.Summary, err = renderTemplate("summary", t.Summary, data)
+	if err != nil {
+		return t, err
+	}
+	t.Description, err = renderTemplate("description", t.Description, data)
+	if err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// Apply sets the fields defined by the template on the issue config.
+// The description is only set if it's empty in the issue config, while the
+// summary replaces the existing one since it can include it using {{.Summary}}.
+func (t IssueTemplate) Apply(ic *IssueConfig) {
+	if t.Summary != "" {
+		ic.Summary = t.Summary
+	}
+	if ic.Description == "" {
+		ic.Description = t.Description
+	}
+	if t.IssueType != "" {
+		ic.IssueType = t.IssueType
+	}
+	if t.ComponentNames != nil {
+		ic.ComponentNames = t.ComponentNames
+	}
+	ic.Labels = append(ic.Labels, t.Labels...)
+	for k, v := range t.CustomFields {
+		if ic.CustomFields == nil {
+			ic.CustomFields = make(map[string]any, len(t.CustomFields))
+		}
+		ic.CustomFields[k] = v
+	}
+}
+
+func renderTemplate(name, text string, data TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,182 @@
+package jt
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, "templates")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatalf("failed to create templates dir: %s", err)
+	}
+	for name, content := range map[string]string{
+		"bug.yaml":   "summary: 'Bug: {{.Summary}}'\nissueType: Bug\nlabels: [from-dir]\n",
+		"spike.yaml": "summary: Spike from dir\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write template: %s", err)
+		}
+	}
+
+	conf := JTConfig{
+		Templates:    map[string]IssueTemplate{"spike": {Summary: "Spike from config"}},
+		TemplatesDir: "~/templates",
+	}
+
+	testData := []struct {
+		name     string
+		conf     JTConfig
+		template string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "config",
+			conf:     conf,
+			template: "spike",
+			expected: "Spike from config",
+		},
+		{
+			name:     "templates dir",
+			conf:     conf,
+			template: "bug",
+			expected: "Bug: {{.Summary}}",
+		},
+		{
+			name:     "not found",
+			conf:     conf,
+			template: "missing",
+			errMsg:   `template "missing" not found in config or`,
+		},
+		{
+			name:     "no templates dir",
+			conf:     JTConfig{},
+			template: "bug",
+			errMsg:   `template "bug" not found`,
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadTemplate(tt.conf, tt.template)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("expected error message to contain %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to load template: %s", err)
+			}
+			if got.Summary != tt.expected {
+				t.Fatalf("expected summary %q, got %q", tt.expected, got.Summary)
+			}
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := IssueTemplate{
+		Summary:     "[{{.Project}}] {{.Summary}}",
+		Description: "Found on {{.Branch}}",
+		IssueType:   "Bug",
+	}
+	got, err := tmpl.Render(TemplateData{Summary: "Crash", Project: "ABC", Branch: "main"})
+	if err != nil {
+		t.Fatalf("failed to render template: %s", err)
+	}
+	if got.Summary != "[ABC] Crash" {
+		t.Fatalf("expected summary %q, got %q", "[ABC] Crash", got.Summary)
+	}
+	if got.Description != "Found on main" {
+		t.Fatalf("expected description %q, got %q", "Found on main", got.Description)
+	}
+	if got.IssueType != "Bug" {
+		t.Fatalf("expected issue type %q, got %q", "Bug", got.IssueType)
+	}
+
+	if _, err := (IssueTemplate{Summary: "{{.Missing}}"}).Render(TemplateData{}); err == nil {
+		t.Fatalf("expected an error for an unknown variable")
+	}
+}
+
+func TestTemplateApply(t *testing.T) {
+	tmpl := IssueTemplate{
+		Summary:        "Bug: crash",
+		Description:    "Template description",
+		IssueType:      "Bug",
+		Labels:         []string{"triage"},
+		ComponentNames: []string{"api"},
+		CustomFields:   map[string]any{"customfield_10010": 3},
+	}
+
+	ic := IssueConfig{
+		Summary:        "crash",
+		Description:    "Provided description",
+		IssueType:      "Task",
+		Labels:         []string{"provided"},
+		ComponentNames: []string{"web"},
+	}
+	tmpl.Apply(&ic)
+
+	if ic.Summary != "Bug: crash" {
+		t.Fatalf("expected summary %q, got %q", "Bug: crash", ic.Summary)
+	}
+	if ic.Description != "Provided description" {
+		t.Fatalf("expected description %q, got %q", "Provided description", ic.Description)
+	}
+	if ic.IssueType != "Bug" {
+		t.Fatalf("expected issue type %q, got %q", "Bug", ic.IssueType)
+	}
+	if !slices.Equal(ic.Labels, []string{"provided", "triage"}) {
+		t.Fatalf("expected labels %q, got %q", []string{"provided", "triage"}, ic.Labels)
+	}
+	if !slices.Equal(ic.ComponentNames, []string{"api"}) {
+		t.Fatalf("expected components %q, got %q", []string{"api"}, ic.ComponentNames)
+	}
+	if ic.CustomFields["customfield_10010"] != 3 {
+		t.Fatalf("expected custom field %v, got %v", 3, ic.CustomFields["customfield_10010"])
+	}
+
+	// The description is only set from the template if there isn't one.
+	ic = IssueConfig{}
+	tmpl.Apply(&ic)
+	if ic.Description != "Template description" {
+		t.Fatalf("expected description %q, got %q", "Template description", ic.Description)
+	}
+}
+
+func TestReadConfigTemplatesDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("url: https://example.atlassian.net\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+
+	// The templates directory defaults to the one next to the config file.
+	conf, err := ReadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %s", err)
+	}
+	if expected := filepath.Join(dir, "templates"); conf.TemplatesDir != expected {
+		t.Fatalf("expected templates dir %q, got %q", expected, conf.TemplatesDir)
+	}
+
+	if err := os.WriteFile(path, []byte("templatesDir: /elsewhere\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+	conf, err = ReadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %s", err)
+	}
+	if conf.TemplatesDir != "/elsewhere" {
+		t.Fatalf("expected templates dir %q, got %q", "/elsewhere", conf.TemplatesDir)
+	}
+}